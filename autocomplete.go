@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	imgui "github.com/AllenDang/cimgui-go"
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/completion"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// the maximum number of candidates shown in the autocomplete popup
+const maxAutocomplete = 10
+
+// the maximum length of a cell result shown as the detail of a candidate
+const maxAutocompleteResult = 30
+
+// the input flags required by an input widget that uses autocompletion
+const autocompleteFlags = giu.InputTextFlagsCallbackAlways |
+	giu.InputTextFlagsCallbackCompletion |
+	giu.InputTextFlagsCallbackHistory
+
+// the state of the autocomplete popup. there is only ever one active input
+// widget so the state can be shared by the cell editor and the formula bar
+type autocomplete struct {
+	// the partial word at the cursor and where it starts in the entry
+	start int
+	word  string
+
+	candidates []completion.Candidate
+	selected   int
+}
+
+// the candidates for completion. if the word being completed is a reference
+// then the candidates are references to cells that have a result. otherwise,
+// the candidates are the named operators of the engine and the user-defined
+// names
+func (iv *ivycel) completionCandidates(reference bool) []completion.Candidate {
+	var cs []completion.Candidate
+
+	if reference {
+		rows, columns := iv.worksheet.Size()
+		for col := range columns {
+			for row := range rows {
+				cell := iv.worksheet.Cell(row, col)
+				r := strings.TrimSpace(cell.Result())
+				if r == "" || cell.Error() != nil {
+					continue
+				}
+				if len(r) > maxAutocompleteResult {
+					r = fmt.Sprintf("%s...", r[:maxAutocompleteResult])
+				}
+				cs = append(cs, completion.Candidate{
+					Text:   references.WrapCellReference(cell.Position().Reference()),
+					Detail: r,
+				})
+			}
+		}
+		return cs
+	}
+
+	for _, op := range iv.ivy.Operators() {
+		var detail []string
+		if op.Unary != "" {
+			detail = append(detail, fmt.Sprintf("%s y: %s", op.Name, op.Unary))
+		}
+		if op.Binary != "" {
+			detail = append(detail, fmt.Sprintf("x %s y: %s", op.Name, op.Binary))
+		}
+		cs = append(cs, completion.Candidate{Text: op.Name, Detail: strings.Join(detail, ", ")})
+	}
+
+	assigned := iv.worksheet.Names()
+	for _, n := range iv.ivy.Names() {
+		detail := "variable"
+		if ps := assigned[n]; len(ps) > 0 {
+			detail = fmt.Sprintf("variable assigned in %s", references.WrapCellReference(ps[0].Reference()))
+		}
+		cs = append(cs, completion.Candidate{Text: n, Detail: detail})
+	}
+
+	return cs
+}
+
+// update the candidates for the word at the cursor position. the candidates
+// are only changed if the word has changed
+func (iv *ivycel) updateAutocomplete(entry string, cursor int) {
+	start, word := completion.Word(entry, cursor)
+
+	ac := &iv.autocomplete
+	if start == ac.start && word == ac.word {
+		return
+	}
+
+	ac.start = start
+	ac.word = word
+	ac.selected = 0
+	ac.candidates = completion.Match(word, iv.completionCandidates(strings.HasPrefix(word, "{")))
+	if len(ac.candidates) > maxAutocomplete {
+		ac.candidates = ac.candidates[:maxAutocomplete]
+	}
+}
+
+// autocompleteCallback should be called from the callback function of any
+// input widget that uses autocompletion. the tab key accepts the selected
+// candidate and the up/down keys change the selected candidate. returns true
+// if the callback event has been dealt with
+func (iv *ivycel) autocompleteCallback(data *imgui.InputTextCallbackData) bool {
+	ac := &iv.autocomplete
+
+	switch data.EventFlag() {
+	case imgui.InputTextFlagsCallbackCompletion:
+		iv.updateAutocomplete(data.Buf(), int(data.CursorPos()))
+		if len(ac.candidates) == 0 {
+			return true
+		}
+		data.DeleteChars(int32(ac.start), int32(len(ac.word)))
+		data.InsertChars(int32(ac.start), ac.candidates[ac.selected].Text)
+		ac.candidates = nil
+		return true
+
+	case imgui.InputTextFlagsCallbackHistory:
+		if len(ac.candidates) == 0 {
+			return true
+		}
+		switch data.EventKey() {
+		case imgui.KeyUpArrow:
+			ac.selected = (ac.selected + len(ac.candidates) - 1) % len(ac.candidates)
+		case imgui.KeyDownArrow:
+			ac.selected = (ac.selected + 1) % len(ac.candidates)
+		}
+		return true
+	}
+
+	iv.updateAutocomplete(data.Buf(), int(data.CursorPos()))
+	return false
+}
+
+// draw the autocomplete popup below the input widget most recently built. the
+// popup is only drawn if the input widget is active
+func (iv *ivycel) autocompletePopup() {
+	ac := &iv.autocomplete
+	if !imgui.IsItemActive() || len(ac.candidates) == 0 {
+		return
+	}
+
+	var width int
+	for _, c := range ac.candidates {
+		width = max(width, len(c.Text))
+	}
+
+	imgui.SetNextWindowPos(imgui.Vec2{X: imgui.ItemRectMin().X, Y: imgui.ItemRectMax().Y})
+	if imgui.BeginTooltip() {
+		for i, c := range ac.candidates {
+			giu.Selectable(fmt.Sprintf("%-*s  %s", width, c.Text, c.Detail)).
+				Selected(i == ac.selected).
+				Build()
+		}
+		imgui.EndTooltip()
+	}
+}
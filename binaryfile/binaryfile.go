@@ -0,0 +1,92 @@
+package binaryfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+var IllegalWidth = errors.New("illegal element width")
+var IllegalRowSize = errors.New("illegal row size")
+
+// Read length bytes from the file starting at offset. a length of zero reads
+// to the end of the file
+func Read(filename string, offset int64, length int64) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if offset < 0 || length < 0 {
+		return nil, errors.New("offset and length must not be negative")
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = f
+	if length > 0 {
+		r = io.LimitReader(f, length)
+	}
+
+	return io.ReadAll(r)
+}
+
+// Row is a single row of values in a dump. the address is the offset of the
+// first byte in the row
+type Row struct {
+	Address int64
+	Values  []uint64
+}
+
+// Dump divides the data into rows of values. each value is made up of width
+// bytes in the specified byte order. the address is the offset of the first
+// byte in data. if the data is not a multiple of width then the final value is
+// padded with zeroes
+func Dump(data []byte, address int64, bytesPerRow int, width int, order engine.ByteOrder) ([]Row, error) {
+	switch width {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("%w: %d", IllegalWidth, width)
+	}
+
+	if bytesPerRow < width || bytesPerRow%width != 0 {
+		return nil, fmt.Errorf("%w: %d bytes is not a multiple of the element width", IllegalRowSize, bytesPerRow)
+	}
+
+	var rows []Row
+
+	for i := 0; i < len(data); i += bytesPerRow {
+		row := Row{Address: address + int64(i)}
+
+		end := min(i+bytesPerRow, len(data))
+		for j := i; j < end; j += width {
+			e := make([]byte, width)
+			copy(e, data[j:min(j+width, end)])
+			row.Values = append(row.Values, Value(e, order))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Value combines the bytes into a single value using the byte order
+func Value(b []byte, order engine.ByteOrder) uint64 {
+	var v uint64
+	for i := range b {
+		if order == engine.LittleEndian {
+			v |= uint64(b[i]) << (8 * i)
+		} else {
+			v = (v << 8) | uint64(b[i])
+		}
+	}
+	return v
+}
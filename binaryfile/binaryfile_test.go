@@ -0,0 +1,76 @@
+package binaryfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/binaryfile"
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("%v is an unexpected error", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.bin")
+	err := os.WriteFile(fn, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 0o600)
+	ExpectedError(t, err, nil)
+
+	b, err := binaryfile.Read(fn, 0, 0)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, len(b), 10)
+
+	b, err = binaryfile.Read(fn, 2, 3)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, len(b), 3)
+	ExpectEquality(t, b[0], 2)
+	ExpectEquality(t, b[2], 4)
+
+	// reading past the end of the file is not an error
+	b, err = binaryfile.Read(fn, 8, 10)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, len(b), 2)
+}
+
+func TestDump(t *testing.T) {
+	data := []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99}
+
+	rows, err := binaryfile.Dump(data, 0x100, 4, 1, engine.BigEndian)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, len(rows), 3)
+	ExpectEquality(t, rows[1].Address, 0x104)
+	ExpectEquality(t, len(rows[1].Values), 4)
+	ExpectEquality(t, rows[1].Values[0], 0x44)
+	ExpectEquality(t, len(rows[2].Values), 2)
+
+	rows, err = binaryfile.Dump(data, 0, 4, 2, engine.BigEndian)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, rows[0].Values[0], 0x0011)
+	ExpectEquality(t, rows[0].Values[1], 0x2233)
+
+	rows, err = binaryfile.Dump(data, 0, 8, 4, engine.LittleEndian)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, rows[0].Values[0], 0x33221100)
+	ExpectEquality(t, rows[0].Values[1], 0x77665544)
+
+	// final value is padded with zeroes
+	ExpectEquality(t, rows[1].Values[0], 0x00009988)
+
+	_, err = binaryfile.Dump(data, 0, 16, 3, engine.BigEndian)
+	ExpectedError(t, err, binaryfile.IllegalWidth)
+	_, err = binaryfile.Dump(data, 0, 6, 4, engine.BigEndian)
+	ExpectedError(t, err, binaryfile.IllegalRowSize)
+}
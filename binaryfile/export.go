@@ -0,0 +1,236 @@
+package binaryfile
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+// Format is the type of file produced by Export()
+type Format int
+
+const (
+	FormatBinary Format = iota
+	FormatC
+	FormatGo
+	FormatIntelHex
+	FormatSRecord
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatBinary:
+		return "Raw binary"
+	case FormatC:
+		return "C array"
+	case FormatGo:
+		return "Go slice"
+	case FormatIntelHex:
+		return "Intel HEX"
+	case FormatSRecord:
+		return "Motorola S-record"
+	}
+	return "unknown format"
+}
+
+// Formats is the list of all formats in the order they should be presented
+var Formats = []Format{FormatBinary, FormatC, FormatGo, FormatIntelHex, FormatSRecord}
+
+// InRange returns true if the value can be stored in width bytes. negative
+// values are allowed and will be stored as two's complement
+func InRange(v *big.Int, width int) bool {
+	lim := new(big.Int).Lsh(big.NewInt(1), uint(width*8))
+	neg := new(big.Int).Neg(new(big.Int).Rsh(lim, 1))
+	return v.Cmp(lim) < 0 && v.Cmp(neg) >= 0
+}
+
+// unsigned returns the value as it would be stored in width bytes
+func unsigned(v *big.Int, width int) *big.Int {
+	if v.Sign() >= 0 {
+		return v
+	}
+	lim := new(big.Int).Lsh(big.NewInt(1), uint(width*8))
+	return new(big.Int).Add(v, lim)
+}
+
+// Encode the values as bytes. each value occupies width bytes in the byte
+// order. the values should have been checked with InRange() first
+func Encode(values []*big.Int, width int, order engine.ByteOrder) ([]byte, error) {
+	switch width {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("%w: %d", IllegalWidth, width)
+	}
+
+	var data []byte
+	for _, v := range values {
+		if !InRange(v, width) {
+			return nil, fmt.Errorf("%v does not fit in %d bytes", v, width)
+		}
+		b := unsigned(v, width).FillBytes(make([]byte, width))
+		if order == engine.LittleEndian {
+			for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+				b[i], b[j] = b[j], b[i]
+			}
+		}
+		data = append(data, b...)
+	}
+
+	return data, nil
+}
+
+// the number of values on each line of a source code literal
+const valuesPerLine = 8
+
+// format values as a list of hexadecimal literals with a trailing comma. lines
+// are indented by indent
+func literals(values []*big.Int, width int, indent string) string {
+	var s strings.Builder
+	for i, v := range values {
+		if i%valuesPerLine == 0 {
+			if i > 0 {
+				s.WriteString("\n")
+			}
+			s.WriteString(indent)
+		} else {
+			s.WriteString(" ")
+		}
+		fmt.Fprintf(&s, "0x%0*x,", width*2, unsigned(v, width))
+	}
+	s.WriteString("\n")
+	return s.String()
+}
+
+// CArray returns the values as a C array literal. if there is more than one
+// row then the array is two dimensional
+func CArray(name string, values [][]*big.Int, width int) string {
+	typ := fmt.Sprintf("uint%d_t", width*8)
+
+	var s strings.Builder
+	if len(values) == 1 {
+		fmt.Fprintf(&s, "const %s %s[%d] = {\n", typ, name, len(values[0]))
+		s.WriteString(literals(values[0], width, "\t"))
+	} else {
+		fmt.Fprintf(&s, "const %s %s[%d][%d] = {\n", typ, name, len(values), len(values[0]))
+		for _, row := range values {
+			s.WriteString("\t{\n")
+			s.WriteString(literals(row, width, "\t\t"))
+			s.WriteString("\t},\n")
+		}
+	}
+	s.WriteString("};\n")
+	return s.String()
+}
+
+// GoSlice returns the values as a Go slice literal. if there is more than one
+// row then the slice is a slice of slices
+func GoSlice(name string, values [][]*big.Int, width int) string {
+	typ := fmt.Sprintf("uint%d", width*8)
+
+	var s strings.Builder
+	if len(values) == 1 {
+		fmt.Fprintf(&s, "var %s = []%s{\n", name, typ)
+		s.WriteString(literals(values[0], width, "\t"))
+	} else {
+		fmt.Fprintf(&s, "var %s = [][]%s{\n", name, typ)
+		for _, row := range values {
+			s.WriteString("\t{\n")
+			s.WriteString(literals(row, width, "\t\t"))
+			s.WriteString("\t},\n")
+		}
+	}
+	s.WriteString("}\n")
+	return s.String()
+}
+
+// the number of data bytes in each record of an Intel HEX or S-record file
+const bytesPerRecord = 16
+
+// IntelHex returns the data as the contents of an Intel HEX file. extended
+// linear address records are used when the data crosses a 64K boundary
+func IntelHex(data []byte, address uint32) string {
+	var s strings.Builder
+
+	record := func(typ byte, addr uint16, b []byte) {
+		sum := byte(len(b)) + byte(addr>>8) + byte(addr) + typ
+		fmt.Fprintf(&s, ":%02X%04X%02X", len(b), addr, typ)
+		for _, v := range b {
+			fmt.Fprintf(&s, "%02X", v)
+			sum += v
+		}
+		fmt.Fprintf(&s, "%02X\n", byte(-sum))
+	}
+
+	// the upper 16 bits of the address are zero until an extended linear
+	// address record says otherwise
+	var upper uint16
+
+	for i := 0; i < len(data); {
+		a := address + uint32(i)
+
+		if uint16(a>>16) != upper {
+			upper = uint16(a >> 16)
+			record(0x04, 0, []byte{byte(upper >> 8), byte(upper)})
+		}
+
+		// records must not cross a 64K boundary
+		n := min(bytesPerRecord, len(data)-i, int(0x10000-(a&0xffff)))
+		record(0x00, uint16(a), data[i:i+n])
+		i += n
+	}
+
+	record(0x01, 0, nil)
+
+	return s.String()
+}
+
+// SRecord returns the data as the contents of a Motorola S-record file. the
+// size of the address field is chosen to fit the highest address
+func SRecord(data []byte, address uint32) string {
+	var s strings.Builder
+
+	record := func(typ byte, addrBytes int, addr uint32, b []byte) {
+		count := addrBytes + len(b) + 1
+		sum := byte(count)
+		fmt.Fprintf(&s, "S%d%02X", typ, count)
+		for i := addrBytes - 1; i >= 0; i-- {
+			v := byte(addr >> (8 * i))
+			fmt.Fprintf(&s, "%02X", v)
+			sum += v
+		}
+		for _, v := range b {
+			fmt.Fprintf(&s, "%02X", v)
+			sum += v
+		}
+		fmt.Fprintf(&s, "%02X\n", ^sum)
+	}
+
+	// choose the data and termination record types for the address size
+	end := uint64(address) + uint64(len(data))
+	dataType, termType, addrBytes := byte(1), byte(9), 2
+	if end > 0x10000 {
+		dataType, termType, addrBytes = 2, 8, 3
+	}
+	if end > 0x1000000 {
+		dataType, termType, addrBytes = 3, 7, 4
+	}
+
+	record(0, 2, 0, nil)
+
+	var count int
+	for i := 0; i < len(data); i += bytesPerRecord {
+		n := min(bytesPerRecord, len(data)-i)
+		record(dataType, addrBytes, address+uint32(i), data[i:i+n])
+		count++
+	}
+
+	if count <= 0xffff {
+		record(5, 2, uint32(count), nil)
+	}
+
+	record(termType, addrBytes, address, nil)
+
+	return s.String()
+}
@@ -0,0 +1,72 @@
+package binaryfile_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/binaryfile"
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func values(v ...int64) []*big.Int {
+	var r []*big.Int
+	for _, i := range v {
+		r = append(r, big.NewInt(i))
+	}
+	return r
+}
+
+func TestInRange(t *testing.T) {
+	ExpectEquality(t, binaryfile.InRange(big.NewInt(255), 1), true)
+	ExpectEquality(t, binaryfile.InRange(big.NewInt(256), 1), false)
+	ExpectEquality(t, binaryfile.InRange(big.NewInt(-128), 1), true)
+	ExpectEquality(t, binaryfile.InRange(big.NewInt(-129), 1), false)
+	ExpectEquality(t, binaryfile.InRange(big.NewInt(65535), 2), true)
+}
+
+func TestEncode(t *testing.T) {
+	b, err := binaryfile.Encode(values(0x1234, -1), 2, engine.BigEndian)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, string(b), "\x12\x34\xff\xff")
+
+	b, err = binaryfile.Encode(values(0x1234), 4, engine.LittleEndian)
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, string(b), "\x34\x12\x00\x00")
+
+	_, err = binaryfile.Encode(values(256), 1, engine.LittleEndian)
+	ExpectEquality(t, err != nil, true)
+}
+
+func TestSourceLiterals(t *testing.T) {
+	ExpectEquality(t, binaryfile.CArray("table", [][]*big.Int{values(1, 2, 255)}, 1),
+		"const uint8_t table[3] = {\n\t0x01, 0x02, 0xff,\n};\n")
+
+	ExpectEquality(t, binaryfile.CArray("table", [][]*big.Int{values(1, 2), values(3, -1)}, 2),
+		"const uint16_t table[2][2] = {\n\t{\n\t\t0x0001, 0x0002,\n\t},\n\t{\n\t\t0x0003, 0xffff,\n\t},\n};\n")
+
+	ExpectEquality(t, binaryfile.GoSlice("table", [][]*big.Int{values(1, 2, 255)}, 1),
+		"var table = []uint8{\n\t0x01, 0x02, 0xff,\n}\n")
+}
+
+func TestIntelHex(t *testing.T) {
+	// example from the Intel HEX specification
+	ExpectEquality(t, binaryfile.IntelHex([]byte{0x02, 0x33, 0x7a}, 0x0030),
+		":0300300002337A1E\n:00000001FF\n")
+
+	// extended linear address record is required
+	ExpectEquality(t, binaryfile.IntelHex([]byte{0x01}, 0x00010000),
+		":020000040001F9\n:0100000001FE\n:00000001FF\n")
+
+	// record split at 64K boundary
+	ExpectEquality(t, binaryfile.IntelHex([]byte{0x01, 0x02}, 0xffff),
+		":01FFFF000100\n:020000040001F9\n:0100000002FD\n:00000001FF\n")
+}
+
+func TestSRecord(t *testing.T) {
+	ExpectEquality(t, binaryfile.SRecord([]byte{0x01, 0x02}, 0x1000),
+		"S0030000FC\nS10510000102E7\nS5030001FB\nS9031000EC\n")
+
+	// addresses above 64K require S2 records
+	ExpectEquality(t, binaryfile.SRecord([]byte{0xff}, 0x10000),
+		"S0030000FC\nS205010000FFFA\nS5030001FB\nS804010000FA\n")
+}
@@ -0,0 +1,147 @@
+package bitfields
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+var IllegalLayout = errors.New("illegal bitfield layout")
+
+// match a single field in a layout. for example, "EN[0]", "MODE[3:1]" or
+// "DIV[15:8]:16". the optional number after the closing bracket is the number
+// base the field should be displayed in
+var fieldMatch = regexp.MustCompile(`^([[:alpha:]_][[:alnum:]_]*)\[([[:digit:]]+)(?::([[:digit:]]+))?\](?::([[:digit:]]+))?$`)
+
+// Field is a named range of bits in a value
+type Field struct {
+	Name string
+	High int
+	Low  int
+
+	// the number base the field should be displayed in. a value of zero means
+	// that the field is displayed in the output base of the cell
+	Base int
+}
+
+// Width returns the number of bits in the field
+func (f Field) Width() int {
+	return f.High - f.Low + 1
+}
+
+// Mask returns the value that isolates the field once it has been shifted
+// down to bit zero
+func (f Field) Mask() *big.Int {
+	m := big.NewInt(1)
+	m.Lsh(m, uint(f.Width()))
+	return m.Sub(m, big.NewInt(1))
+}
+
+// Extract returns the value of the field in v
+func (f Field) Extract(v *big.Int) *big.Int {
+	r := new(big.Int).Rsh(v, uint(f.Low))
+	return r.And(r, f.Mask())
+}
+
+// Expression returns an engine expression that extracts the field from the
+// cell reference. numbers in the expression are written in the input base so
+// that they are interpreted correctly by the engine
+func (f Field) Expression(ref string, inputBase int) string {
+	return fmt.Sprintf("((%s >> %s) and %s)", ref,
+		engine.FormatInteger(big.NewInt(int64(f.Low)).Text(inputBase)),
+		engine.FormatInteger(f.Mask().Text(inputBase)))
+}
+
+func (f Field) String() string {
+	var s string
+	if f.High == f.Low {
+		s = fmt.Sprintf("%s[%d]", f.Name, f.Low)
+	} else {
+		s = fmt.Sprintf("%s[%d:%d]", f.Name, f.High, f.Low)
+	}
+	if f.Base != 0 {
+		s = fmt.Sprintf("%s:%d", s, f.Base)
+	}
+	return s
+}
+
+// Layout is a list of fields that describes how a value is divided up
+type Layout struct {
+	Fields []Field
+}
+
+// Parse a layout specification. fields are separated by spaces. for example:
+//
+//	EN[0] MODE[3:1] DIV[15:8]:16
+func Parse(spec string) (Layout, error) {
+	var l Layout
+
+	names := make(map[string]bool)
+
+	for _, s := range strings.Fields(spec) {
+		m := fieldMatch.FindStringSubmatch(s)
+		if m == nil {
+			return Layout{}, fmt.Errorf("%w: malformed field %s", IllegalLayout, s)
+		}
+
+		f := Field{Name: m[1]}
+
+		// errors from Atoi() can be ignored because the regex guarantees that
+		// the strings are numeric
+		f.High, _ = strconv.Atoi(m[2])
+		f.Low = f.High
+		if m[3] != "" {
+			f.Low, _ = strconv.Atoi(m[3])
+		}
+		if m[4] != "" {
+			f.Base, _ = strconv.Atoi(m[4])
+			if f.Base < engine.MinBase || f.Base > engine.MaxBase {
+				return Layout{}, fmt.Errorf("%w: base of %s must be between %d and %d", IllegalLayout, f.Name, engine.MinBase, engine.MaxBase)
+			}
+		}
+
+		if f.Low > f.High {
+			return Layout{}, fmt.Errorf("%w: high bit of %s is lower than the low bit", IllegalLayout, f.Name)
+		}
+
+		if names[f.Name] {
+			return Layout{}, fmt.Errorf("%w: field %s appears more than once", IllegalLayout, f.Name)
+		}
+		names[f.Name] = true
+
+		l.Fields = append(l.Fields, f)
+	}
+
+	if len(l.Fields) == 0 {
+		return Layout{}, fmt.Errorf("%w: no fields", IllegalLayout)
+	}
+
+	return l, nil
+}
+
+// Field returns the named field
+func (l Layout) Field(name string) (Field, bool) {
+	for _, f := range l.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// String returns the layout in the form accepted by Parse()
+func (l Layout) String() string {
+	var s strings.Builder
+	for i, f := range l.Fields {
+		if i > 0 {
+			s.WriteString(" ")
+		}
+		s.WriteString(f.String())
+	}
+	return s.String()
+}
@@ -0,0 +1,94 @@
+package bitfields_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/bitfields"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("%v is an unexpected error", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	l, err := bitfields.Parse("EN[0] MODE[3:1] DIV[15:8]:16")
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, len(l.Fields), 3)
+	ExpectEquality(t, l.Fields[0], bitfields.Field{Name: "EN", High: 0, Low: 0})
+	ExpectEquality(t, l.Fields[1], bitfields.Field{Name: "MODE", High: 3, Low: 1})
+	ExpectEquality(t, l.Fields[2], bitfields.Field{Name: "DIV", High: 15, Low: 8, Base: 16})
+
+	// the string form of the layout is the same as the specification
+	ExpectEquality(t, l.String(), "EN[0] MODE[3:1] DIV[15:8]:16")
+
+	f, ok := l.Field("MODE")
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, f.Width(), 3)
+	_, ok = l.Field("FOO")
+	ExpectEquality(t, ok, false)
+
+	// the smallest and largest bases
+	l, err = bitfields.Parse("LOW[3:0]:2 HIGH[7:4]:36")
+	ExpectedError(t, err, nil)
+	ExpectEquality(t, l.Fields[0].Base, 2)
+	ExpectEquality(t, l.Fields[1].Base, 36)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"EN",
+		"EN[]",
+		"EN[0",
+		"1EN[0]",
+		"EN[a]",
+		"MODE[1:3]",
+		"EN[0] EN[1]",
+		"EN[0]:",
+		"EN[0]:0",
+		"EN[0]:1",
+		"EN[0]:37",
+		"EN[0]:99",
+	}
+
+	for _, tst := range tests {
+		_, err := bitfields.Parse(tst)
+		ExpectedError(t, err, bitfields.IllegalLayout)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	l, err := bitfields.Parse("EN[0] MODE[3:1] DIV[15:8]")
+	ExpectedError(t, err, nil)
+
+	v := big.NewInt(0xab0d)
+
+	f, _ := l.Field("EN")
+	ExpectEquality(t, f.Extract(v).Int64(), 1)
+	f, _ = l.Field("MODE")
+	ExpectEquality(t, f.Extract(v).Int64(), 6)
+	f, _ = l.Field("DIV")
+	ExpectEquality(t, f.Extract(v).Int64(), 0xab)
+}
+
+func TestExpression(t *testing.T) {
+	l, err := bitfields.Parse("EN[0] DIV[15:8]")
+	ExpectedError(t, err, nil)
+
+	f, _ := l.Field("DIV")
+	ExpectEquality(t, f.Expression("{A1}", 10), "(({A1} >> 8) and 255)")
+	ExpectEquality(t, f.Expression("{A1}", 16), "(({A1} >> 8) and 0ff)")
+	ExpectEquality(t, f.Expression("{A1}", 2), "(({A1} >> 1000) and 11111111)")
+}
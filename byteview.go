@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// word sizes offered in the bytes menu
+var byteViewSizes = []int{2, 4, 8}
+
+// state of the reassemble bytes dialog
+type reassembleBytes struct {
+	// the cell that will be given the reassembly expression
+	cell *cells.Cell
+
+	// the first cell in the range of bytes. the range runs along the row
+	from  string
+	count int32
+	order engine.ByteOrder
+	err   error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the bytes menu for a cell. the menu allows the user to see an integer result
+// as a sequence of bytes and to reassemble a range of bytes into an integer
+func (iv *ivycel) bytesMenu(cell *cells.Cell) giu.Widget {
+	bv := cell.ByteView()
+
+	return giu.Menu("Bytes").Layout(
+		giu.MenuItem("Off").Selected(!bv.Enabled()).OnClick(func() {
+			cell.SetByteView(engine.ByteView{})
+			iv.worksheet.RecalculateAll()
+		}),
+		giu.Custom(func() {
+			for _, sz := range byteViewSizes {
+				giu.MenuItem(fmt.Sprintf("%d bytes", sz)).Selected(bv.Size == sz).OnClick(func() {
+					nbv := bv
+					nbv.Size = sz
+					cell.SetByteView(nbv)
+					iv.worksheet.RecalculateAll()
+				}).Build()
+			}
+		}),
+		giu.Spacing(),
+		giu.Separator(),
+		giu.Spacing(),
+		giu.Custom(func() {
+			for _, o := range []engine.ByteOrder{engine.BigEndian, engine.LittleEndian} {
+				giu.MenuItem(o.String()).Selected(bv.Order == o).Enabled(bv.Enabled()).OnClick(func() {
+					nbv := bv
+					nbv.Order = o
+					cell.SetByteView(nbv)
+					iv.worksheet.RecalculateAll()
+				}).Build()
+			}
+		}),
+		giu.MenuItem("Spill into cells").Selected(bv.Spill).Enabled(bv.Enabled()).OnClick(func() {
+			nbv := bv
+			nbv.Spill = !nbv.Spill
+			cell.SetByteView(nbv)
+			iv.worksheet.RecalculateAll()
+		}),
+		giu.Spacing(),
+		giu.Separator(),
+		giu.Spacing(),
+		giu.MenuItem("Reassemble Bytes...").Enabled(!cell.ReadOnly()).OnClick(func() {
+			iv.reassembleBytes = reassembleBytes{
+				cell:   cell,
+				count:  4,
+				open:   true,
+				active: true,
+			}
+		}),
+	)
+}
+
+// the reassemble bytes dialog sets the entry of a cell to an expression that
+// combines a range of bytes into an integer
+func (iv *ivycel) reassembleBytesModal() giu.Widget {
+	const popupName = "Reassemble Bytes"
+
+	return giu.Custom(func() {
+		if !iv.reassembleBytes.active {
+			return
+		}
+
+		if iv.reassembleBytes.open {
+			iv.reassembleBytes.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var errLabel giu.Widget
+		if iv.reassembleBytes.err != nil {
+			errLabel = giu.Label(iv.reassembleBytes.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		rb := &iv.reassembleBytes
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label(fmt.Sprintf("Reassemble bytes into %s", rb.cell.Position().Reference())),
+			giu.Label("First byte (eg. A1)"),
+			giu.InputText(&rb.from).Size(100),
+			giu.Label("Number of bytes along the row"),
+			giu.InputInt(&rb.count).Size(100),
+			giu.Row(
+				giu.RadioButton(engine.BigEndian.String(), rb.order == engine.BigEndian).OnChange(func() {
+					rb.order = engine.BigEndian
+				}),
+				giu.RadioButton(engine.LittleEndian.String(), rb.order == engine.LittleEndian).OnChange(func() {
+					rb.order = engine.LittleEndian
+				}),
+			),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					p, err := cells.PositionFromReference(rb.from)
+					if err != nil {
+						rb.err = err
+						return
+					}
+					if rb.count < 1 {
+						rb.err = errors.New("number of bytes must be at least one")
+						return
+					}
+
+					var refs []string
+					for i := range int(rb.count) {
+						refs = append(refs, references.WrapCellReference(
+							p.Adjust(cells.Adjustment{Column: i}).Reference()))
+					}
+
+					bv := engine.ByteView{Size: int(rb.count), Order: rb.order}
+					rb.cell.Entry = bv.Reassemble(refs, rb.cell.Base().Input)
+					rb.cell.Commit(true)
+					iv.worksheet.RecalculateAll()
+
+					rb.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					rb.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
@@ -2,6 +2,7 @@ package cells
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"unicode/utf8"
 
@@ -10,12 +11,32 @@ import (
 
 var UnsupportedShape = errors.New("result is an unsupported shape")
 var PartlyObscured = errors.New("result is partly obscured")
+var DuplicateName = errors.New("name is assigned in more than one cell")
+var CircularReference = errors.New("cell depends on itself")
+var NotConverged = errors.New("iterative calculation did not converge")
 
 type CellID string
 
 type Worksheet interface {
 	RelativeCell(root *Cell, pos Position) *Cell
 	Position(CellID) Position
+
+	// replace range and field references in the expression with an expression
+	// that the engine can understand. numbers in the replacement should be in
+	// the input base
+	ExpandReferences(ex string, inputBase int) (string, error)
+
+	// returns a DependencyError if any cell referred to in the expression
+	// has an evaluation error. the error should be nil otherwise
+	ReferencedError(ex string) error
+
+	// returns a DuplicateName error if the expression assigns to a name that
+	// is also assigned to by the expression in another cell
+	NameConflict(ex string, pos Position) error
+
+	// returns an error if the value of the cell fails the validation rule for
+	// the cell. the reject value is true if the entry should not be accepted
+	Validate(c *Cell) (reject bool, err error)
 }
 
 type Cell struct {
@@ -23,16 +44,43 @@ type Cell struct {
 	worksheet Worksheet
 	id        CellID
 
+	// the base of the cell. a zero field means that the default base in the
+	// engine is used for that part of the base
 	base engine.Base
 
+	// the engine settings of the cell. a zero field means that the default
+	// setting in the engine is used
+	settings engine.Settings
+
+	// how the result is presented in the cell
+	display engine.Display
+
+	// the result as a sequence of bytes. the byte view takes precedence over
+	// the display setting
+	byteView engine.ByteView
+
+	// the name of the bitfield layout used by the cell. the layout itself is
+	// stored in the worksheet
+	layout string
+
 	Entry  string
 	result string
 	err    error
 	warn   error
 
+	// the error from the engine when evaluating the entry. the err field is
+	// also set when evalErr is set but not every error is an evaluation error.
+	// an error in how the result is presented leaves the engine with a valid
+	// value for the cell
+	evalErr error
+
 	parent   *Cell
 	children []*Cell
 
+	// the number of times the cell has been committed. information derived
+	// from the cell only needs to be updated when the generation changes
+	generation int
+
 	User any
 }
 
@@ -41,7 +89,6 @@ func NewCell(engine engine.Interface, worksheet Worksheet, id CellID) *Cell {
 		id:        id,
 		engine:    engine,
 		worksheet: worksheet,
-		base:      engine.Base(),
 	}
 }
 
@@ -62,6 +109,8 @@ func (c *Cell) Commit(force bool) {
 		return
 	}
 
+	c.generation++
+
 	// clear previous results from child cells
 	for _, child := range c.children {
 		child.Entry = ""
@@ -72,6 +121,7 @@ func (c *Cell) Commit(force bool) {
 	// reset other fields
 	c.result = ""
 	c.err = nil
+	c.evalErr = nil
 	c.warn = nil
 	c.parent = nil
 
@@ -84,20 +134,81 @@ func (c *Cell) Commit(force bool) {
 		return
 	}
 
-	var err error
+	// assigning to a name that is assigned to in another cell means the value
+	// of the name depends on the order in which the cells are committed
+	c.warn = c.worksheet.NameConflict(c.Entry, c.Position())
+
+	// a cell that refers to a cell in error is also in error. the cell is not
+	// executed because any error from the engine would not be meaningful
+	if err := c.worksheet.ReferencedError(c.Entry); err != nil {
+		c.evaluationFailed(err)
+		return
+	}
+
+	// the engine settings apply to the execution of the cell and its children
+	// and to the display of the results
+	err := c.engine.WithSettings(c.Settings(), c.execute)
+	if err != nil {
+		c.evaluationFailed(err)
+	}
+	if c.err != nil {
+		return
+	}
+
+	// a value that fails validation either puts the cell in error or is
+	// accepted with a warning
+	reject, err := c.worksheet.Validate(c)
+	if reject {
+		c.err = err
+	} else if err != nil {
+		c.AddWarning(err)
+	}
+}
+
+// execute the entry of the cell and spill the result into child cells
+func (c *Cell) execute() {
+	// expand any range and field references before passing the entry to the
+	// engine
+	ex, err := c.worksheet.ExpandReferences(c.Entry, c.Base().Input)
+	if err != nil {
+		c.evaluationFailed(err)
+		return
+	}
+
 	var r string
 
 	// execute contents of cell
-	c.engine.WithNumberBase(c.base, func() {
-		r, err = c.engine.Execute(c.Position().Reference(), c.Entry)
+	baseErr := c.engine.WithNumberBase(c.Base(), func() {
+		r, err = c.engine.Execute(c.Position().Reference(), ex)
 	})
+	if baseErr != nil {
+		c.evaluationFailed(baseErr)
+		return
+	}
 	if err != nil {
-		c.err = err
+		c.evaluationFailed(err)
 		return
 	}
 
 	r = strings.TrimSpace(r)
 
+	// the byte view replaces the result with a sequence of bytes. if the bytes
+	// are spilled then they are separated by spaces and will be placed in the
+	// child cells in the same way as any other multi-part result
+	if c.byteView.Enabled() {
+		var exact string
+		c.engine.WithErrorSupression(func() {
+			exact, err = c.engine.ExactValue(c.Position().Reference())
+		})
+		if err == nil {
+			r, err = c.byteView.Format(exact, c.Base().Output)
+		}
+		if err != nil {
+			c.err = err
+			return
+		}
+	}
+
 	// do nothing if there are results
 	colSplit := strings.Fields(r)
 	if len(colSplit) == 0 {
@@ -138,11 +249,18 @@ func (c *Cell) Commit(force bool) {
 					break // for loop
 				}
 
+				// don't overwrite the entry of another cell
+				if rel.Entry != "" {
+					c.warn = errors.Join(c.warn, PartlyObscured)
+					break // for loop
+				}
+
 				c.children = append(c.children, rel)
 				rel.Entry = ""
 				rel.parent = c
 				rel.result = ""
 				rel.err = nil
+				rel.evalErr = nil
 			}
 			continue // for rowSplit loop
 		}
@@ -168,9 +286,10 @@ func (c *Cell) Commit(force bool) {
 				break // for loop
 			}
 
-			// don't overwrite existing results
-			if rel.result != "" {
-				c.warn = PartlyObscured
+			// don't overwrite existing results or the entry of another cell. a
+			// cell can have an entry without a result if it is in error
+			if rel.result != "" || rel.Entry != "" {
+				c.warn = errors.Join(c.warn, PartlyObscured)
 				break // for loop
 			}
 
@@ -178,12 +297,53 @@ func (c *Cell) Commit(force bool) {
 			rel.Entry = strings.TrimSpace(cv)
 			rel.parent = c
 			c.engine.WithErrorSupression(func() {
-				c.engine.WithNumberBase(c.base.OutputOnly(), func() {
-					rel.result, rel.err = c.engine.Execute(rel.Position().Reference(), rel.Entry)
+				baseErr := c.engine.WithNumberBase(c.Base().OutputOnly(), func() {
+					rel.result, rel.evalErr = c.engine.Execute(rel.Position().Reference(), rel.Entry)
 				})
+				if baseErr != nil {
+					rel.evalErr = baseErr
+				}
+				rel.err = rel.evalErr
 			})
 		}
 	}
+
+	c.applyDisplay()
+}
+
+// apply the display setting to the results of the cell and its children
+func (c *Cell) applyDisplay() {
+	if c.display == engine.DisplayValue || c.byteView.Enabled() {
+		return
+	}
+
+	apply := func(cell *Cell, ref string) {
+		if cell.err != nil || cell.result == "" {
+			return
+		}
+
+		var exact string
+		var err error
+		c.engine.WithErrorSupression(func() {
+			exact, err = c.engine.ExactValue(ref)
+		})
+		if err == nil {
+			cell.result, err = c.display.Format(exact, c.Base().Output)
+		}
+		if err != nil {
+			cell.err = err
+		}
+	}
+
+	apply(c, fmt.Sprintf("%s%s", c.Position().Reference(), c.RootIndex(c.Settings().Origin)))
+	for _, child := range c.children {
+		apply(child, child.Position().Reference())
+	}
+}
+
+// Generation returns a number that changes every time the cell is committed
+func (c *Cell) Generation() int {
+	return c.generation
 }
 
 func (c *Cell) Parent() *Cell {
@@ -208,6 +368,25 @@ func (c *Cell) Error() error {
 	return nil
 }
 
+// EvalError returns the error for the cell if the error happened when the
+// engine evaluated the cell. an error in how the result is presented is not
+// returned because the engine still has a valid value for the cell
+func (c *Cell) EvalError() error {
+	if c.evalErr != nil {
+		return c.evalErr
+	}
+	if c.parent != nil {
+		return c.parent.EvalError()
+	}
+	return nil
+}
+
+// the engine could not evaluate the cell
+func (c *Cell) evaluationFailed(err error) {
+	c.err = err
+	c.evalErr = err
+}
+
 func (c *Cell) Warning() error {
 	if c.warn != nil {
 		return c.warn
@@ -218,29 +397,139 @@ func (c *Cell) Warning() error {
 	return nil
 }
 
+// AddWarning adds a warning to the cell. the warning is cleared the next time
+// the cell is committed
+func (c *Cell) AddWarning(warn error) {
+	c.warn = errors.Join(c.warn, warn)
+}
+
 // if cell has a parent then it should be treated as read-only
 func (c *Cell) ReadOnly() bool {
 	return c.parent != nil
 }
 
+// Base returns the effective number base of the cell
 func (c *Cell) Base() engine.Base {
 	if c.parent != nil {
-		return c.parent.base
+		return c.parent.Base()
+	}
+	return c.base.Resolve(c.engine.Base())
+}
+
+// BaseOverride returns the number base that has been set for the cell. a zero
+// field indicates that the default base is being used for that part of the
+// base
+func (c *Cell) BaseOverride() engine.Base {
+	if c.parent != nil {
+		return c.parent.BaseOverride()
 	}
 	return c.base
 }
 
-func (c *Cell) SetBase(b engine.Base) {
+// SetBase changes the number base of the cell. a zero field in the base means
+// that the default base will be used for that part of the base. a child cell
+// changes the base of its parent. the base is checked by the engine first and
+// the cell is left unchanged if the base is not acceptable
+func (c *Cell) SetBase(b engine.Base) error {
 	if c.parent != nil {
-		c.parent.base = b
-		return
+		return c.parent.SetBase(b)
+	}
+	err := c.engine.ValidateBase(b.Resolve(c.engine.Base()))
+	if err != nil {
+		return err
 	}
 	c.base = b
 	c.Commit(false)
+	return nil
+}
+
+// Settings returns the effective engine settings of the cell
+func (c *Cell) Settings() engine.Settings {
+	if c.parent != nil {
+		return c.parent.Settings()
+	}
+	return c.settings.Resolve(c.engine.Settings())
+}
+
+// SettingsOverride returns the engine settings that have been set for the
+// cell. a zero field indicates that the default is being used for that setting
+func (c *Cell) SettingsOverride() engine.Settings {
+	if c.parent != nil {
+		return c.parent.SettingsOverride()
+	}
+	return c.settings
+}
+
+// SetSettings changes the engine settings of the cell. a zero field means that
+// the default will be used for that setting. a child cell changes the settings
+// of its parent. the settings are checked by the engine first and the cell is
+// left unchanged if they are not acceptable
+func (c *Cell) SetSettings(s engine.Settings) error {
+	if c.parent != nil {
+		return c.parent.SetSettings(s)
+	}
+	err := c.engine.ValidateSettings(s.Resolve(c.engine.Settings()))
+	if err != nil {
+		return err
+	}
+	c.settings = s
+	c.Commit(false)
+	return nil
+}
+
+// Display returns how the result of the cell is presented
+func (c *Cell) Display() engine.Display {
+	if c.parent != nil {
+		return c.parent.Display()
+	}
+	return c.display
+}
+
+// SetDisplay changes how the result of the cell is presented. a child cell
+// changes the display of its parent
+func (c *Cell) SetDisplay(d engine.Display) {
+	if c.parent != nil {
+		c.parent.SetDisplay(d)
+		return
+	}
+	c.display = d
+	c.Commit(false)
+}
+
+// ByteView returns how the result of the cell is presented as a sequence of
+// bytes
+func (c *Cell) ByteView() engine.ByteView {
+	if c.parent != nil {
+		return c.parent.ByteView()
+	}
+	return c.byteView
+}
+
+// SetByteView changes how the result of the cell is presented as a sequence of
+// bytes. a child cell changes the byte view of its parent
+func (c *Cell) SetByteView(bv engine.ByteView) {
+	if c.parent != nil {
+		c.parent.SetByteView(bv)
+		return
+	}
+	c.byteView = bv
+	c.Commit(false)
+}
+
+// Layout returns the name of the bitfield layout used by the cell. an empty
+// string means that the cell has no layout
+func (c *Cell) Layout() string {
+	return c.layout
+}
+
+// SetLayout sets the name of the bitfield layout used by the cell
+func (c *Cell) SetLayout(layout string) {
+	c.layout = layout
 }
 
-// return the index for the root value of the cell depending on the shape of the value
-func (c *Cell) RootIndex() string {
+// return the index for the root value of the cell depending on the shape of the
+// value. the index will be used in an expression executed with the origin
+func (c *Cell) RootIndex(origin engine.Origin) string {
 	if !c.HasChildren() {
 		return ""
 	}
@@ -248,8 +537,5 @@ func (c *Cell) RootIndex() string {
 	shape := c.engine.Shape(c.Position().Reference())
 	n := len(strings.Fields(shape))
 
-	// the Repeat() function assumes that we're counting from 1 inside the
-	// engine. this is the default but maybe we should account for that possibly
-	// being changed
-	return strings.Repeat("[1]", n)
+	return strings.Repeat(fmt.Sprintf("[%d]", origin.Index()), n)
 }
@@ -0,0 +1,19 @@
+package cells
+
+import "fmt"
+
+// DependencyError is the error for a cell whose entry refers to a cell that is
+// in error. the origin is the cell where the error first happened and the
+// wrapped error is the error in that cell
+type DependencyError struct {
+	Origin Position
+	Err    error
+}
+
+func (e DependencyError) Error() string {
+	return fmt.Sprintf("depends on error in {%s}", e.Origin.Reference())
+}
+
+func (e DependencyError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,20 @@
+package cells_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+func TestDependencyError(t *testing.T) {
+	root := errors.New("division by zero")
+	var err error = cells.DependencyError{Origin: cells.Position{Row: 0, Column: 1}, Err: root}
+
+	ExpectEquality(t, err.Error(), "depends on error in {B1}")
+	ExpectedError(t, err, root)
+
+	var dep cells.DependencyError
+	ExpectEquality(t, errors.As(err, &dep), true)
+	ExpectEquality(t, dep.Origin.Reference(), "B1")
+}
@@ -42,6 +42,14 @@ func (p Position) Adjust(adj Adjustment) Position {
 	}
 }
 
+// limit position so that it is inside a worksheet of the specified size
+func (p Position) Clamp(rows, columns int) Position {
+	return Position{
+		Row:    max(0, min(p.Row, rows-1)),
+		Column: max(0, min(p.Column, columns-1)),
+	}
+}
+
 func (p Position) Reference() string {
 	if p.IsError() {
 		return ""
@@ -84,3 +84,14 @@ func TestPositionFromReference(t *testing.T) {
 		}
 	}
 }
+
+func TestPositionClamp(t *testing.T) {
+	p := cells.Position{Row: 3, Column: 4}
+	ExpectEquality(t, p.Clamp(10, 10), p)
+
+	// moving past the edges of the worksheet stops at the edge
+	ExpectEquality(t, p.Adjust(cells.Adjustment{Row: -5}).Clamp(10, 10), cells.Position{Row: 0, Column: 4})
+	ExpectEquality(t, p.Adjust(cells.Adjustment{Column: -5}).Clamp(10, 10), cells.Position{Row: 3, Column: 0})
+	ExpectEquality(t, p.Adjust(cells.Adjustment{Row: 20}).Clamp(10, 10), cells.Position{Row: 9, Column: 4})
+	ExpectEquality(t, p.Adjust(cells.Adjustment{Row: 20, Column: 20}).Clamp(10, 5), cells.Position{Row: 9, Column: 4})
+}
@@ -0,0 +1,71 @@
+package cells
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Range is a rectangular area of cells. the start position is the top-left
+// corner and the end position is the bottom-right corner
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// NewRange returns a range with the two positions as opposite corners. the
+// positions can be in any order
+func NewRange(a Position, b Position) Range {
+	return Range{
+		Start: Position{Row: min(a.Row, b.Row), Column: min(a.Column, b.Column)},
+		End:   Position{Row: max(a.Row, b.Row), Column: max(a.Column, b.Column)},
+	}
+}
+
+// RangeFromReference parses a range reference such as "A1:B5". a single
+// position reference is also accepted and results in a range of one cell
+func RangeFromReference(ref string) (Range, error) {
+	start, end, ok := strings.Cut(ref, ":")
+	if !ok {
+		end = start
+	}
+
+	s, err := PositionFromReference(start)
+	if err != nil {
+		return Range{}, err
+	}
+
+	e, err := PositionFromReference(end)
+	if err != nil {
+		return Range{}, err
+	}
+
+	return NewRange(s, e), nil
+}
+
+// Reference returns the range in the form accepted by RangeFromReference()
+func (r Range) Reference() string {
+	if r.Start == r.End {
+		return r.Start.Reference()
+	}
+	return fmt.Sprintf("%s:%s", r.Start.Reference(), r.End.Reference())
+}
+
+func (r Range) String() string {
+	return r.Reference()
+}
+
+// Contains returns true if the position is inside the range
+func (r Range) Contains(p Position) bool {
+	return p.Row >= r.Start.Row && p.Row <= r.End.Row &&
+		p.Column >= r.Start.Column && p.Column <= r.End.Column
+}
+
+// Rows returns the number of rows in the range
+func (r Range) Rows() int {
+	return r.End.Row - r.Start.Row + 1
+}
+
+// Columns returns the number of columns in the range
+func (r Range) Columns() int {
+	return r.End.Column - r.Start.Column + 1
+}
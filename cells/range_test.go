@@ -0,0 +1,55 @@
+package cells_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+func TestRangeFromReference(t *testing.T) {
+	type testSpec struct {
+		ref        string
+		err        error
+		normalised string
+		rows       int
+		columns    int
+	}
+	tests := []testSpec{
+		{ref: "A1:B5", rows: 5, columns: 2},
+		{ref: "C3", rows: 1, columns: 1},
+		{ref: "AA10:AB10", rows: 1, columns: 2},
+
+		// corners are normalised so that the start is the top-left
+		{ref: "B5:A1", normalised: "A1:B5", rows: 5, columns: 2},
+		{ref: "A5:B1", normalised: "A1:B5", rows: 5, columns: 2},
+		{ref: "C3:C3", normalised: "C3", rows: 1, columns: 1},
+
+		// illegal ranges
+		{ref: "A1:", err: cells.IllegalReference},
+		{ref: ":B5", err: cells.IllegalReference},
+		{ref: "A1:B0", err: cells.IllegalReference},
+	}
+
+	for _, tst := range tests {
+		r, err := cells.RangeFromReference(tst.ref)
+		ExpectedError(t, err, tst.err)
+		if err == nil {
+			if tst.normalised == "" {
+				ExpectEquality(t, r.String(), tst.ref)
+			} else {
+				ExpectEquality(t, r.String(), tst.normalised)
+			}
+			ExpectEquality(t, r.Rows(), tst.rows)
+			ExpectEquality(t, r.Columns(), tst.columns)
+		}
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	r := cells.NewRange(cells.Position{Row: 4, Column: 1}, cells.Position{Row: 0, Column: 0})
+	ExpectEquality(t, r.Contains(cells.Position{Row: 0, Column: 0}), true)
+	ExpectEquality(t, r.Contains(cells.Position{Row: 4, Column: 1}), true)
+	ExpectEquality(t, r.Contains(cells.Position{Row: 2, Column: 1}), true)
+	ExpectEquality(t, r.Contains(cells.Position{Row: 5, Column: 1}), false)
+	ExpectEquality(t, r.Contains(cells.Position{Row: 2, Column: 2}), false)
+}
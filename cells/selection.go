@@ -0,0 +1,117 @@
+package cells
+
+import (
+	"strings"
+)
+
+// Selection is a list of ranges. the ranges may overlap. the last range in the
+// list is the active range and is the range that is changed when the selection
+// is extended
+type Selection struct {
+	ranges []Range
+
+	// the anchor is the corner of the active range that stays in place when the
+	// selection is extended. the cursor is the opposite corner
+	anchor Position
+	cursor Position
+}
+
+// NewSelection returns a selection of a single cell
+func NewSelection(p Position) Selection {
+	var s Selection
+	s.Set(p)
+	return s
+}
+
+// Set the selection to a single cell
+func (s *Selection) Set(p Position) {
+	s.ranges = []Range{NewRange(p, p)}
+	s.anchor = p
+	s.cursor = p
+}
+
+// Extend the active range so that it runs from the anchor to the position
+func (s *Selection) Extend(p Position) {
+	if len(s.ranges) == 0 {
+		s.Set(p)
+		return
+	}
+	s.cursor = p
+	s.ranges[len(s.ranges)-1] = NewRange(s.anchor, s.cursor)
+}
+
+// Add a new range of a single cell to the selection. the new range becomes the
+// active range
+func (s *Selection) Add(p Position) {
+	s.ranges = append(s.ranges, NewRange(p, p))
+	s.anchor = p
+	s.cursor = p
+}
+
+// Cursor returns the corner of the active range that moves when the selection
+// is extended
+func (s Selection) Cursor() Position {
+	return s.cursor
+}
+
+// Active returns the active range
+func (s Selection) Active() Range {
+	if len(s.ranges) == 0 {
+		return Range{}
+	}
+	return s.ranges[len(s.ranges)-1]
+}
+
+// Ranges returns all ranges in the selection
+func (s Selection) Ranges() []Range {
+	return s.ranges
+}
+
+// Contains returns true if the position is in any of the ranges in the
+// selection
+func (s Selection) Contains(p Position) bool {
+	for _, r := range s.ranges {
+		if r.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSingle returns true if the selection is of a single cell
+func (s Selection) IsSingle() bool {
+	return len(s.ranges) == 1 && s.ranges[0].Start == s.ranges[0].End
+}
+
+// Positions returns every position in the selection. a position that appears
+// in more than one range is only returned once. positions are in the order of
+// the ranges and then by row and column
+func (s Selection) Positions() []Position {
+	var ps []Position
+	seen := make(map[Position]bool)
+	for _, r := range s.ranges {
+		for row := r.Start.Row; row <= r.End.Row; row++ {
+			for col := r.Start.Column; col <= r.End.Column; col++ {
+				p := Position{Row: row, Column: col}
+				if !seen[p] {
+					seen[p] = true
+					ps = append(ps, p)
+				}
+			}
+		}
+	}
+	return ps
+}
+
+// Reference returns the ranges in the selection separated by commas
+func (s Selection) Reference() string {
+	var refs []string
+	for _, r := range s.ranges {
+		refs = append(refs, r.Reference())
+	}
+	return strings.Join(refs, ",")
+}
+
+func (s Selection) String() string {
+	return s.Reference()
+}
@@ -0,0 +1,42 @@
+package cells_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+func TestSelection(t *testing.T) {
+	s := cells.NewSelection(cells.Position{Row: 1, Column: 1})
+	ExpectEquality(t, s.String(), "B2")
+	ExpectEquality(t, s.IsSingle(), true)
+
+	// extending the selection keeps the anchor in place
+	s.Extend(cells.Position{Row: 3, Column: 2})
+	ExpectEquality(t, s.String(), "B2:C4")
+	s.Extend(cells.Position{Row: 0, Column: 0})
+	ExpectEquality(t, s.String(), "A1:B2")
+	ExpectEquality(t, s.IsSingle(), false)
+	ExpectEquality(t, len(s.Positions()), 4)
+
+	// adding a range creates a new active range
+	s.Add(cells.Position{Row: 5, Column: 5})
+	ExpectEquality(t, s.String(), "A1:B2,F6")
+	s.Extend(cells.Position{Row: 6, Column: 5})
+	ExpectEquality(t, s.String(), "A1:B2,F6:F7")
+	ExpectEquality(t, s.Active().String(), "F6:F7")
+	ExpectEquality(t, s.Cursor().String(), "F7")
+
+	ExpectEquality(t, s.Contains(cells.Position{Row: 1, Column: 1}), true)
+	ExpectEquality(t, s.Contains(cells.Position{Row: 6, Column: 5}), true)
+	ExpectEquality(t, s.Contains(cells.Position{Row: 3, Column: 3}), false)
+
+	// overlapping ranges don't produce duplicate positions
+	s.Add(cells.Position{Row: 0, Column: 0})
+	ExpectEquality(t, len(s.Positions()), 6)
+
+	// setting the selection removes all ranges
+	s.Set(cells.Position{Row: 9, Column: 9})
+	ExpectEquality(t, s.String(), "J10")
+	ExpectEquality(t, len(s.Ranges()), 1)
+}
@@ -0,0 +1,61 @@
+package completion
+
+import (
+	"strings"
+)
+
+// Candidate is a possible completion of a word. the detail is a short
+// description of the candidate and is not inserted into the entry
+type Candidate struct {
+	Text   string
+	Detail string
+}
+
+func isWordCharacter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Word returns the partial word that ends at the cursor and the position in
+// the entry where the word starts. a word preceded by an opening brace is a
+// cell reference and the brace is included in the word. a word that starts
+// with a digit is a number and is not returned
+func Word(entry string, cursor int) (int, string) {
+	cursor = min(max(cursor, 0), len(entry))
+
+	start := cursor
+	for start > 0 && isWordCharacter(entry[start-1]) {
+		start--
+	}
+
+	if start > 0 && entry[start-1] == '{' {
+		start--
+	} else if start < cursor && entry[start] >= '0' && entry[start] <= '9' {
+		return cursor, ""
+	}
+
+	return start, entry[start:cursor]
+}
+
+// Match returns the candidates that begin with the word. candidates that are
+// the same as the word are not returned because there is nothing to complete.
+// matching for cell references is not case sensitive. the returned candidates
+// are in the same order as they were supplied
+func Match(word string, candidates []Candidate) []Candidate {
+	if word == "" {
+		return nil
+	}
+
+	reference := strings.HasPrefix(word, "{")
+	if reference {
+		word = strings.ToUpper(word)
+	}
+
+	var m []Candidate
+	for _, c := range candidates {
+		if c.Text != word && strings.HasPrefix(c.Text, word) {
+			m = append(m, c)
+		}
+	}
+
+	return m
+}
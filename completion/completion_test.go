@@ -0,0 +1,70 @@
+package completion_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/completion"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func TestWord(t *testing.T) {
+	type test struct {
+		entry  string
+		cursor int
+		start  int
+		word   string
+	}
+
+	var testingTable = []test{
+		{entry: "", cursor: 0, start: 0, word: ""},
+		{entry: "rh", cursor: 2, start: 0, word: "rh"},
+		{entry: "2 2 rh", cursor: 6, start: 4, word: "rh"},
+		{entry: "2 2 rh iota 4", cursor: 6, start: 4, word: "rh"},
+		{entry: "sqrt {A", cursor: 7, start: 5, word: "{A"},
+		{entry: "sqrt {B1", cursor: 8, start: 5, word: "{B1"},
+		{entry: "(x+", cursor: 2, start: 1, word: "x"},
+		{entry: "1+2", cursor: 3, start: 3, word: ""},
+		{entry: "1+23", cursor: 4, start: 4, word: ""},
+		{entry: "x+", cursor: 2, start: 2, word: ""},
+	}
+
+	for _, tst := range testingTable {
+		start, word := completion.Word(tst.entry, tst.cursor)
+		ExpectEquality(t, start, tst.start)
+		ExpectEquality(t, word, tst.word)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	candidates := []completion.Candidate{
+		{Text: "rot"},
+		{Text: "rho"},
+		{Text: "real"},
+		{Text: "iota"},
+		{Text: "{A1}"},
+		{Text: "{A10}"},
+	}
+
+	m := completion.Match("r", candidates)
+	ExpectEquality(t, len(m), 3)
+	ExpectEquality(t, m[0].Text, "rot")
+	ExpectEquality(t, m[1].Text, "rho")
+	ExpectEquality(t, m[2].Text, "real")
+
+	m = completion.Match("rho", candidates)
+	ExpectEquality(t, len(m), 0)
+
+	m = completion.Match("", candidates)
+	ExpectEquality(t, len(m), 0)
+
+	// cell references are not case sensitive
+	m = completion.Match("{a1", candidates)
+	ExpectEquality(t, len(m), 2)
+	ExpectEquality(t, m[0].Text, "{A1}")
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	imgui "github.com/AllenDang/cimgui-go"
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+// state of the data table window. the values are entered as comma separated
+// lists of expressions
+type dataTable struct {
+	formula      string
+	rowInput     string
+	rowValues    string
+	columnInput  string
+	columnValues string
+
+	// the values used for the most recent table. the table has a row for
+	// each row value and a column for each column value. a table with one
+	// variable has a single column
+	rows    []string
+	columns []string
+	table   [][]string
+	err     error
+
+	// the data table window is open
+	active bool
+}
+
+// open the data table window with the cell as the formula
+func (iv *ivycel) openDataTable(cell *cells.Cell) {
+	dt := &iv.dataTable
+	dt.formula = cell.Position().Reference()
+	dt.table = nil
+	dt.err = nil
+	dt.active = true
+}
+
+// split a comma separated list of values
+func splitValues(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// fill the data table with the result of the formula for each value
+func (iv *ivycel) fillDataTable() error {
+	dt := &iv.dataTable
+	dt.table = nil
+
+	cell := func(ref string) (*cells.Cell, error) {
+		p, err := cells.PositionFromReference(strings.TrimSpace(ref))
+		if err != nil {
+			return nil, err
+		}
+		rows, columns := iv.worksheet.Size()
+		if p.Row >= rows || p.Column >= columns {
+			return nil, fmt.Errorf("{%s} is outside the worksheet", p.Reference())
+		}
+		return iv.worksheet.Cell(p.Row, p.Column), nil
+	}
+
+	formula, err := cell(dt.formula)
+	if err != nil {
+		return fmt.Errorf("formula cell: %w", err)
+	}
+	rowInput, err := cell(dt.rowInput)
+	if err != nil {
+		return fmt.Errorf("row input cell: %w", err)
+	}
+	if rowInput.ReadOnly() {
+		return fmt.Errorf("row input cell: {%s} is part of the result of another cell", rowInput.Position().Reference())
+	}
+	rows := splitValues(dt.rowValues)
+	if len(rows) == 0 {
+		return errors.New("no row values")
+	}
+
+	// the column input is optional
+	var columnInput *cells.Cell
+	columns := []string{""}
+	if strings.TrimSpace(dt.columnInput) != "" {
+		columnInput, err = cell(dt.columnInput)
+		if err != nil {
+			return fmt.Errorf("column input cell: %w", err)
+		}
+		if columnInput.ReadOnly() {
+			return fmt.Errorf("column input cell: {%s} is part of the result of another cell", columnInput.Position().Reference())
+		}
+		columns = splitValues(dt.columnValues)
+		if len(columns) == 0 {
+			return errors.New("no column values")
+		}
+	}
+
+	dt.rows = rows
+	dt.columns = columns
+	if columnInput == nil {
+		dt.table = iv.worksheet.DataTable(formula, rowInput, rows, nil, nil)
+	} else {
+		dt.table = iv.worksheet.DataTable(formula, rowInput, rows, columnInput, columns)
+	}
+
+	return nil
+}
+
+// copy the data table to the clipboard as tab separated values
+func (iv *ivycel) copyDataTable() {
+	dt := &iv.dataTable
+
+	var s strings.Builder
+	s.WriteString(dt.formula)
+	for _, c := range dt.columns {
+		fmt.Fprintf(&s, "\t%s", c)
+	}
+	s.WriteString("\n")
+	for r, row := range dt.table {
+		s.WriteString(dt.rows[r])
+		for _, v := range row {
+			fmt.Fprintf(&s, "\t%s", v)
+		}
+		s.WriteString("\n")
+	}
+
+	imgui.SetClipboardText(s.String())
+}
+
+// the data table window shows the result of a formula cell for a list of
+// values substituted into one or two input cells. the cells themselves are
+// never changed
+func (iv *ivycel) dataTableWindow() {
+	dt := &iv.dataTable
+	if !dt.active {
+		return
+	}
+
+	var errLabel giu.Widget
+	if dt.err != nil {
+		errLabel = giu.Label(dt.err.Error())
+	} else {
+		errLabel = giu.Label("")
+	}
+
+	var table giu.Widget = giu.Label("")
+	if dt.table != nil {
+		header := []*giu.TableColumnWidget{giu.TableColumn(dt.formula)}
+		for _, c := range dt.columns {
+			header = append(header, giu.TableColumn(c))
+		}
+
+		var rows []*giu.TableRowWidget
+		for r, row := range dt.table {
+			w := []giu.Widget{giu.Label(dt.rows[r])}
+			for _, v := range row {
+				w = append(w, giu.Label(v))
+			}
+			rows = append(rows, giu.TableRow(w...))
+		}
+
+		table = giu.Table().
+			Flags(giu.TableFlagsBorders | giu.TableFlagsRowBg).
+			Columns(header...).
+			Rows(rows...)
+	}
+
+	giu.Window("Data Table").IsOpen(&dt.active).Size(400, 400).Layout(
+		giu.Row(giu.Label("Formula cell       "), giu.InputText(&dt.formula).Size(100)),
+		giu.Row(giu.Label("Row input cell     "), giu.InputText(&dt.rowInput).Size(100)),
+		giu.Row(giu.Label("Row values         "), giu.InputText(&dt.rowValues).Size(-1)),
+		giu.Row(giu.Label("Column input cell  "), giu.InputText(&dt.columnInput).Size(100)),
+		giu.Row(giu.Label("Column values      "), giu.InputText(&dt.columnValues).Size(-1)),
+		giu.Label("Values are separated by commas. The column input is optional"),
+		giu.Row(
+			giu.Button("Fill").OnClick(func() {
+				dt.err = iv.fillDataTable()
+			}),
+			giu.Button("Copy").Disabled(dt.table == nil).OnClick(func() {
+				iv.copyDataTable()
+			}),
+		),
+		errLabel,
+		giu.Separator(),
+		table,
+	)
+}
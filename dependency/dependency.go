@@ -0,0 +1,264 @@
+package dependency
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// Graph is the graph of references between cells. an edge from one cell to
+// another means that the value of the first cell depends on the value of the
+// second cell
+type Graph struct {
+	precedents map[cells.Position][]cells.Position
+	dependents map[cells.Position][]cells.Position
+
+	// the entry of each cell added with AddEntry(). used to label the nodes
+	// in the DOT output
+	entries map[cells.Position]string
+
+	// the names that an edge was added for with AddNameEdge(). used to label
+	// the edges in the DOT output
+	names map[edge][]string
+}
+
+type edge struct {
+	from cells.Position
+	to   cells.Position
+}
+
+// NewGraph returns an empty graph
+func NewGraph() *Graph {
+	return &Graph{
+		precedents: make(map[cells.Position][]cells.Position),
+		dependents: make(map[cells.Position][]cells.Position),
+		entries:    make(map[cells.Position]string),
+		names:      make(map[edge][]string),
+	}
+}
+
+// AddEdge adds an edge from one cell to a cell that it depends on
+func (g *Graph) AddEdge(from cells.Position, to cells.Position) {
+	if slices.Contains(g.precedents[from], to) {
+		return
+	}
+	g.precedents[from] = append(g.precedents[from], to)
+	g.dependents[to] = append(g.dependents[to], from)
+}
+
+// AddEntry adds an edge from the cell to every cell referenced in the entry.
+// every cell in a range reference is a precedent of the cell
+func (g *Graph) AddEntry(p cells.Position, entry string) {
+	g.entries[p] = entry
+	for _, loc := range references.FindReferences(entry) {
+		for row := loc.Range.Start.Row; row <= loc.Range.End.Row; row++ {
+			for col := loc.Range.Start.Column; col <= loc.Range.End.Column; col++ {
+				g.AddEdge(p, cells.Position{Row: row, Column: col})
+			}
+		}
+	}
+}
+
+// AddNameEdge adds an edge from a cell that uses a name to a cell that assigns
+// a value to the name
+func (g *Graph) AddNameEdge(from cells.Position, to cells.Position, name string) {
+	g.AddEdge(from, to)
+	e := edge{from: from, to: to}
+	if !slices.Contains(g.names[e], name) {
+		g.names[e] = append(g.names[e], name)
+	}
+}
+
+// order positions by row and then by column
+func comparePositions(a cells.Position, b cells.Position) int {
+	if c := cmp.Compare(a.Row, b.Row); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Column, b.Column)
+}
+
+// follow the edges from the position and return every position reached. the
+// starting position is only included if there is a cycle back to it
+func walk(edges map[cells.Position][]cells.Position, p cells.Position) []cells.Position {
+	seen := make(map[cells.Position]bool)
+	var visit func(cells.Position)
+	visit = func(p cells.Position) {
+		for _, q := range edges[p] {
+			if !seen[q] {
+				seen[q] = true
+				visit(q)
+			}
+		}
+	}
+	visit(p)
+
+	var ps []cells.Position
+	for q := range seen {
+		ps = append(ps, q)
+	}
+	slices.SortFunc(ps, comparePositions)
+	return ps
+}
+
+// Precedents returns every cell that the cell depends on, directly or
+// transitively. the positions are sorted by row and then column
+func (g *Graph) Precedents(p cells.Position) []cells.Position {
+	return walk(g.precedents, p)
+}
+
+// Dependents returns every cell that depends on the cell, directly or
+// transitively. the positions are sorted by row and then column
+func (g *Graph) Dependents(p cells.Position) []cells.Position {
+	return walk(g.dependents, p)
+}
+
+// Order returns the positions in an order where a cell comes after the cells
+// that it depends on. edges to cells that are not in the list are ignored.
+// cells in a cycle, and cells that are otherwise unordered, keep the order in
+// which they appear in the list
+func (g *Graph) Order(ps []cells.Position) []cells.Position {
+	include := make(map[cells.Position]bool)
+	for _, p := range ps {
+		include[p] = true
+	}
+
+	order := make([]cells.Position, 0, len(ps))
+	seen := make(map[cells.Position]bool)
+	var visit func(cells.Position)
+	visit = func(p cells.Position) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		for _, q := range g.precedents[p] {
+			if include[q] {
+				visit(q)
+			}
+		}
+		order = append(order, p)
+	}
+	for _, p := range ps {
+		visit(p)
+	}
+
+	return order
+}
+
+// Cycles returns the groups of cells that depend on each other, directly or
+// transitively. a cell that depends on itself is a group of one. edges to
+// cells that are not in the list are ignored. the cells in each group, and the
+// groups themselves, are in the order in which the cells appear in the list
+func (g *Graph) Cycles(ps []cells.Position) [][]cells.Position {
+	order := make(map[cells.Position]int)
+	for i, p := range ps {
+		order[p] = i
+	}
+
+	// tarjan's strongly connected components algorithm
+	index := make(map[cells.Position]int)
+	low := make(map[cells.Position]int)
+	onStack := make(map[cells.Position]bool)
+	var stack []cells.Position
+	var cycles [][]cells.Position
+
+	var connect func(cells.Position)
+	connect = func(p cells.Position) {
+		index[p] = len(index)
+		low[p] = index[p]
+		stack = append(stack, p)
+		onStack[p] = true
+
+		for _, q := range g.precedents[p] {
+			if _, ok := order[q]; !ok {
+				continue // for loop
+			}
+			if _, ok := index[q]; !ok {
+				connect(q)
+				low[p] = min(low[p], low[q])
+			} else if onStack[q] {
+				low[p] = min(low[p], index[q])
+			}
+		}
+
+		if low[p] != index[p] {
+			return
+		}
+
+		var cycle []cells.Position
+		for {
+			q := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[q] = false
+			cycle = append(cycle, q)
+			if q == p {
+				break // for loop
+			}
+		}
+		if len(cycle) > 1 || slices.Contains(g.precedents[p], p) {
+			slices.SortFunc(cycle, func(a cells.Position, b cells.Position) int {
+				return cmp.Compare(order[a], order[b])
+			})
+			cycles = append(cycles, cycle)
+		}
+	}
+
+	for _, p := range ps {
+		if _, ok := index[p]; !ok {
+			connect(p)
+		}
+	}
+
+	slices.SortFunc(cycles, func(a []cells.Position, b []cells.Position) int {
+		return cmp.Compare(order[a[0]], order[b[0]])
+	})
+
+	return cycles
+}
+
+// DOT returns the graph in the Graphviz DOT language. nodes are labelled with
+// the cell reference and the entry of the cell
+func (g *Graph) DOT(name string) string {
+	var nodes []cells.Position
+	seen := make(map[cells.Position]bool)
+	add := func(p cells.Position) {
+		if !seen[p] {
+			seen[p] = true
+			nodes = append(nodes, p)
+		}
+	}
+	for from, tos := range g.precedents {
+		add(from)
+		for _, to := range tos {
+			add(to)
+		}
+	}
+	slices.SortFunc(nodes, comparePositions)
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "digraph %q {\n", name)
+	for _, p := range nodes {
+		label := p.Reference()
+		if e := g.entries[p]; e != "" {
+			label = fmt.Sprintf("%s\n%s", label, e)
+		}
+		fmt.Fprintf(&s, "\t%q [label=%q];\n", p.Reference(), label)
+	}
+	for _, from := range nodes {
+		tos := slices.Clone(g.precedents[from])
+		slices.SortFunc(tos, comparePositions)
+		for _, to := range tos {
+			if n := g.names[edge{from: from, to: to}]; len(n) > 0 {
+				fmt.Fprintf(&s, "\t%q -> %q [label=%q];\n", from.Reference(), to.Reference(), strings.Join(n, ", "))
+			} else {
+				fmt.Fprintf(&s, "\t%q -> %q;\n", from.Reference(), to.Reference())
+			}
+		}
+	}
+	s.WriteString("}\n")
+
+	return s.String()
+}
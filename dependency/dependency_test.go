@@ -0,0 +1,137 @@
+package dependency_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/dependency"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func position(t *testing.T, ref string) cells.Position {
+	t.Helper()
+	p, err := cells.PositionFromReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func references(ps []cells.Position) string {
+	var s string
+	for i, p := range ps {
+		if i > 0 {
+			s += " "
+		}
+		s += p.Reference()
+	}
+	return s
+}
+
+func TestGraph(t *testing.T) {
+	g := dependency.NewGraph()
+	g.AddEntry(position(t, "A1"), "1")
+	g.AddEntry(position(t, "A2"), "{A1} + 1")
+	g.AddEntry(position(t, "B1"), "+/{A1:A3}")
+	g.AddEntry(position(t, "C1"), "{B1} * {A2}")
+
+	// A3 is a child of A2
+	g.AddEdge(position(t, "A3"), position(t, "A2"))
+
+	ExpectEquality(t, references(g.Precedents(position(t, "C1"))), "A1 B1 A2 A3")
+	ExpectEquality(t, references(g.Precedents(position(t, "A2"))), "A1")
+	ExpectEquality(t, references(g.Precedents(position(t, "A1"))), "")
+
+	ExpectEquality(t, references(g.Dependents(position(t, "A1"))), "B1 C1 A2 A3")
+	ExpectEquality(t, references(g.Dependents(position(t, "C1"))), "")
+}
+
+func TestGraphCycle(t *testing.T) {
+	g := dependency.NewGraph()
+	g.AddEntry(position(t, "A1"), "{B1}")
+	g.AddEntry(position(t, "B1"), "{A1}")
+
+	ExpectEquality(t, references(g.Precedents(position(t, "A1"))), "A1 B1")
+	ExpectEquality(t, references(g.Dependents(position(t, "A1"))), "A1 B1")
+}
+
+func TestDOT(t *testing.T) {
+	g := dependency.NewGraph()
+	g.AddEntry(position(t, "A2"), "{A1} + 1")
+	g.AddEntry(position(t, "A1"), "2")
+
+	ExpectEquality(t, g.DOT("sheet"), `digraph "sheet" {
+	"A1" [label="A1\n2"];
+	"A2" [label="A2\n{A1} + 1"];
+	"A2" -> "A1";
+}
+`)
+}
+
+func TestNameEdge(t *testing.T) {
+	g := dependency.NewGraph()
+	g.AddEntry(position(t, "A1"), "x = 3")
+	g.AddEntry(position(t, "B1"), "x * 2")
+	g.AddNameEdge(position(t, "B1"), position(t, "A1"), "x")
+
+	ExpectEquality(t, references(g.Precedents(position(t, "B1"))), "A1")
+	ExpectEquality(t, g.DOT("sheet"), `digraph "sheet" {
+	"A1" [label="A1\nx = 3"];
+	"B1" [label="B1\nx * 2"];
+	"B1" -> "A1" [label="x"];
+}
+`)
+}
+
+func TestOrder(t *testing.T) {
+	g := dependency.NewGraph()
+	g.AddEntry(position(t, "A1"), "{B2} + 1")
+	g.AddEntry(position(t, "A2"), "2")
+	g.AddEntry(position(t, "B1"), "{A1} * {C1}")
+	g.AddEntry(position(t, "B2"), "{A2}")
+
+	ps := []cells.Position{
+		position(t, "A1"), position(t, "B1"),
+		position(t, "A2"), position(t, "B2"),
+	}
+	ExpectEquality(t, references(g.Order(ps)), "A2 B2 A1 B1")
+
+	// cells in a cycle keep their order
+	g.AddEntry(position(t, "A2"), "{B1}")
+	ExpectEquality(t, len(g.Order(ps)), 4)
+	ExpectEquality(t, references(g.Order(ps[:2])), "A1 B1")
+}
+
+func TestCycles(t *testing.T) {
+	g := dependency.NewGraph()
+	g.AddEntry(position(t, "A1"), "{B1} + 1")
+	g.AddEntry(position(t, "B1"), "{C1} / 2")
+	g.AddEntry(position(t, "C1"), "{A1}")
+	g.AddEntry(position(t, "D1"), "{A1}")
+	g.AddEntry(position(t, "A2"), "{A2} + 1")
+	g.AddEntry(position(t, "B2"), "{D1}")
+
+	ps := []cells.Position{
+		position(t, "A1"), position(t, "B1"), position(t, "C1"), position(t, "D1"),
+		position(t, "A2"), position(t, "B2"),
+	}
+	cycles := g.Cycles(ps)
+	ExpectEquality(t, len(cycles), 2)
+	ExpectEquality(t, references(cycles[0]), "A1 B1 C1")
+	ExpectEquality(t, references(cycles[1]), "A2")
+
+	// no cycles without the cell that closes the loop
+	ExpectEquality(t, len(g.Cycles(ps[:2])), 0)
+
+	// a cell that depends on a cycle comes after every cell in the cycle
+	order := g.Order(ps)
+	cycles = g.Cycles(order)
+	ExpectEquality(t, references(order), "C1 B1 A1 D1 A2 B2")
+	ExpectEquality(t, references(cycles[0]), "C1 B1 A1")
+}
@@ -1,5 +1,19 @@
 package engine
 
+import (
+	"errors"
+	"fmt"
+)
+
+var InvalidBase = errors.New("invalid number base")
+
+// the range of number bases that can be used. a base outside of this range
+// can't be printed by the math/big and strconv packages
+const (
+	MinBase = 2
+	MaxBase = 36
+)
+
 type Base struct {
 	Input  int
 	Output int
@@ -13,3 +27,28 @@ func (bs Base) OutputOnly() Base {
 		Output: bs.Output,
 	}
 }
+
+// Resolve returns an instance of the Base type where any unset (zero) field has
+// been replaced by the corresponding field in the default base
+func (bs Base) Resolve(def Base) Base {
+	if bs.Input == 0 {
+		bs.Input = def.Input
+	}
+	if bs.Output == 0 {
+		bs.Output = def.Output
+	}
+	return bs
+}
+
+// Check returns an InvalidBase error if either part of the base is outside the
+// range MinBase to MaxBase. an unset (zero) part is not allowed so the base
+// should be resolved first if it can have unset parts
+func (bs Base) Check() error {
+	if bs.Input < MinBase || bs.Input > MaxBase {
+		return fmt.Errorf("%w: input base %d must be between %d and %d", InvalidBase, bs.Input, MinBase, MaxBase)
+	}
+	if bs.Output < MinBase || bs.Output > MaxBase {
+		return fmt.Errorf("%w: output base %d must be between %d and %d", InvalidBase, bs.Output, MinBase, MaxBase)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func TestBaseCheck(t *testing.T) {
+	ExpectEquality(t, engine.Base{Input: 2, Output: 36}.Check(), nil)
+	ExpectEquality(t, engine.Base{Input: 10, Output: 16}.Check(), nil)
+
+	// an unset part is the default base and must be resolved before the base
+	// is checked
+	ExpectedError(t, engine.Base{Input: 10}.Check(), engine.InvalidBase)
+	ExpectEquality(t, engine.Base{Input: 10}.Resolve(engine.Base{Input: 10, Output: 10}).Check(), nil)
+
+	ExpectedError(t, engine.Base{Input: 1, Output: 10}.Check(), engine.InvalidBase)
+	ExpectedError(t, engine.Base{Input: 10, Output: 37}.Check(), engine.InvalidBase)
+}
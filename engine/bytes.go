@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// ByteOrder is the order in which the bytes of an integer are presented
+type ByteOrder int
+
+const (
+	BigEndian ByteOrder = iota
+	LittleEndian
+)
+
+func (o ByteOrder) String() string {
+	switch o {
+	case BigEndian:
+		return "Big endian"
+	case LittleEndian:
+		return "Little endian"
+	}
+	return "unknown byte order"
+}
+
+// ByteView presents an integer result as a sequence of bytes
+type ByteView struct {
+	// the number of bytes in the word. a size of zero means that the byte
+	// view is not being used
+	Size  int
+	Order ByteOrder
+
+	// spill the bytes so there is one byte per cell. if spill is false then
+	// the bytes are shown together in a single cell
+	Spill bool
+}
+
+// Enabled returns true if the byte view is being used
+func (bv ByteView) Enabled() bool {
+	return bv.Size > 0
+}
+
+// Bytes returns the bytes of the integer in the byte order of the view. the
+// exact value should be in a form that can be parsed by the math/big package.
+// negative values are treated as two's complement
+func (bv ByteView) Bytes(exact string) ([]byte, error) {
+	v, ok := new(big.Int).SetString(strings.TrimSpace(exact), 10)
+	if !ok {
+		return nil, NotAnInteger
+	}
+
+	w := uint(bv.Size * 8)
+	lim := new(big.Int).Lsh(big.NewInt(1), w)
+	neg := new(big.Int).Neg(new(big.Int).Rsh(lim, 1))
+	if v.Cmp(lim) >= 0 || v.Cmp(neg) < 0 {
+		return nil, fmt.Errorf("%w: does not fit in %d bytes", OutOfRange, bv.Size)
+	}
+	if v.Sign() < 0 {
+		v.Add(v, lim)
+	}
+
+	b := v.FillBytes(make([]byte, bv.Size))
+	if bv.Order == LittleEndian {
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+	}
+
+	return b, nil
+}
+
+// Format the bytes of the integer in the output base. bytes are separated by a
+// space if the view is spilled. spilled bytes are read by the engine so they
+// are formatted with FormatInteger()
+//
+// bytes that are not spilled are shown together without a separator, so every
+// byte is padded to the width of the largest byte in the output base. this
+// keeps the bytes apart in bases like decimal where the width of a byte is not
+// otherwise fixed
+func (bv ByteView) Format(exact string, outputBase int) (string, error) {
+	b, err := bv.Bytes(exact)
+	if err != nil {
+		return "", err
+	}
+
+	// spilled bytes are padded with leading zeroes in the bases where the
+	// width of a byte is well defined
+	var digits int
+	switch {
+	case !bv.Spill:
+		digits = len(big.NewInt(math.MaxUint8).Text(outputBase))
+	case outputBase == 2 || outputBase == 8 || outputBase == 16:
+		digits = int(math.Ceil(8 / math.Log2(float64(outputBase))))
+	}
+
+	s := make([]string, len(b))
+	for i, v := range b {
+		t := big.NewInt(int64(v)).Text(outputBase)
+		if len(t) < digits {
+			t = fmt.Sprintf("%s%s", strings.Repeat("0", digits-len(t)), t)
+		}
+		if bv.Spill {
+			t = FormatInteger(t)
+		}
+		s[i] = t
+	}
+
+	if bv.Spill {
+		return strings.Join(s, " "), nil
+	}
+	return strings.Join(s, ""), nil
+}
+
+// Reassemble returns an expression that combines the bytes at the cell
+// references into a single integer. the references should be wrapped and be in
+// the byte order of the view. numbers in the expression are written in the
+// input base
+func (bv ByteView) Reassemble(refs []string, inputBase int) string {
+	// the decode operator expects the most significant byte first
+	r := make([]string, len(refs))
+	copy(r, refs)
+	if bv.Order == LittleEndian {
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+	}
+
+	return fmt.Sprintf("%s decode %s", FormatInteger(big.NewInt(256).Text(inputBase)), strings.Join(r, " "))
+}
+
+// FormatInteger makes sure that an integer in text form can be read by the
+// engine. numbers that begin with a letter must be prefixed with a zero
+// otherwise they would be confused with an identifier
+func FormatInteger(s string) string {
+	if len(s) > 0 && s[0] >= 'a' && s[0] <= 'z' {
+		return fmt.Sprintf("0%s", s)
+	}
+	return s
+}
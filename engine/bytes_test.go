@@ -0,0 +1,55 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func TestByteView(t *testing.T) {
+	type test struct {
+		view   engine.ByteView
+		exact  string
+		base   int
+		result string
+		err    error
+	}
+
+	tests := []test{
+		{view: engine.ByteView{Size: 4}, exact: "305419896", base: 16, result: "12345678"},
+		{view: engine.ByteView{Size: 4, Order: engine.LittleEndian}, exact: "305419896", base: 16, result: "78563412"},
+		{view: engine.ByteView{Size: 4, Order: engine.LittleEndian, Spill: true}, exact: "305419896", base: 16, result: "78 56 34 12"},
+		{view: engine.ByteView{Size: 2, Spill: true}, exact: "-1", base: 16, result: "0ff 0ff"},
+		{view: engine.ByteView{Size: 2, Spill: true}, exact: "258", base: 2, result: "00000001 00000010"},
+		{view: engine.ByteView{Size: 2, Spill: true}, exact: "258", base: 10, result: "1 2"},
+
+		// bytes that aren't spilled are never prefixed and are always padded
+		{view: engine.ByteView{Size: 2}, exact: "-1", base: 16, result: "ffff"},
+		{view: engine.ByteView{Size: 2}, exact: "2571", base: 16, result: "0a0b"},
+		{view: engine.ByteView{Size: 2}, exact: "258", base: 10, result: "001002"},
+		{view: engine.ByteView{Size: 2, Order: engine.LittleEndian}, exact: "258", base: 10, result: "002001"},
+
+		// errors
+		{view: engine.ByteView{Size: 2}, exact: "65536", base: 16, err: engine.OutOfRange},
+		{view: engine.ByteView{Size: 2}, exact: "1/2", base: 16, err: engine.NotAnInteger},
+	}
+
+	for _, tst := range tests {
+		r, err := tst.view.Format(tst.exact, tst.base)
+		ExpectedError(t, err, tst.err)
+		ExpectEquality(t, r, tst.result)
+	}
+}
+
+func TestByteViewReassemble(t *testing.T) {
+	refs := []string{"{A1}", "{B1}", "{C1}"}
+
+	bv := engine.ByteView{Size: 3}
+	ExpectEquality(t, bv.Reassemble(refs, 10), "256 decode {A1} {B1} {C1}")
+
+	bv.Order = engine.LittleEndian
+	ExpectEquality(t, bv.Reassemble(refs, 16), "100 decode {C1} {B1} {A1}")
+
+	// the original slice of references is unchanged
+	ExpectEquality(t, refs[0], "{A1}")
+}
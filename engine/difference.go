@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Difference returns the largest absolute difference between the elements of
+// two values. the values should be in the form returned by ExactValue(). if the
+// values have a different number of elements, or if an element that can't be
+// parsed as a number is different, then the difference is infinite
+func Difference(a string, b string) float64 {
+	fa := strings.Fields(a)
+	fb := strings.Fields(b)
+	if len(fa) != len(fb) {
+		return math.Inf(1)
+	}
+
+	var diff float64
+	for i := range fa {
+		if fa[i] == fb[i] {
+			continue // for loop
+		}
+
+		ra, oka := new(big.Rat).SetString(fa[i])
+		rb, okb := new(big.Rat).SetString(fb[i])
+		if !oka || !okb {
+			return math.Inf(1)
+		}
+
+		d, _ := new(big.Rat).Sub(ra, rb).Float64()
+		diff = max(diff, math.Abs(d))
+	}
+
+	return diff
+}
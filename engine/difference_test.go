@@ -0,0 +1,22 @@
+package engine_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func TestDifference(t *testing.T) {
+	ExpectEquality(t, engine.Difference("1", "1"), 0.0)
+	ExpectEquality(t, engine.Difference("1.5", "1"), 0.5)
+	ExpectEquality(t, engine.Difference("1/4", "0.5"), 0.25)
+	ExpectEquality(t, engine.Difference("-2", "1e0"), 3.0)
+	ExpectEquality(t, engine.Difference("1 2 3", "1 2.5 2"), 1.0)
+	ExpectEquality(t, engine.Difference("", ""), 0.0)
+
+	// different shapes and values that are not numbers
+	ExpectEquality(t, engine.Difference("1 2", "1"), math.Inf(1))
+	ExpectEquality(t, engine.Difference("'a'", "'b'"), math.Inf(1))
+	ExpectEquality(t, engine.Difference("'a'", "'a'"), 0.0)
+}
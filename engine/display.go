@@ -0,0 +1,177 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Display is how the result of a cell is presented. it is applied to the
+// result after the output base
+type Display int
+
+const (
+	// the result is shown as it is produced by the engine
+	DisplayValue Display = iota
+
+	// an integer result is treated as a bit pattern and shown as the
+	// floating-point value it represents
+	DisplayFloat32
+	DisplayFloat64
+
+	// a floating-point result is shown as its bit pattern
+	DisplayFloat32Bits
+	DisplayFloat64Bits
+)
+
+var NotAnInteger = errors.New("result is not an integer")
+var NotANumber = errors.New("result is not a number")
+var OutOfRange = errors.New("result is out of range")
+
+func (d Display) String() string {
+	switch d {
+	case DisplayValue:
+		return "Value"
+	case DisplayFloat32:
+		return "Float32 from bits"
+	case DisplayFloat64:
+		return "Float64 from bits"
+	case DisplayFloat32Bits:
+		return "Float32 as bits"
+	case DisplayFloat64Bits:
+		return "Float64 as bits"
+	}
+	return "unknown display"
+}
+
+// IsFloat returns true if the display is one of the IEEE-754 displays
+func (d Display) IsFloat() bool {
+	return d != DisplayValue
+}
+
+// Width returns the number of bits in the IEEE-754 value
+func (d Display) Width() int {
+	switch d {
+	case DisplayFloat32, DisplayFloat32Bits:
+		return 32
+	case DisplayFloat64, DisplayFloat64Bits:
+		return 64
+	}
+	return 0
+}
+
+// Bits returns the bit pattern for the exact value. the exact value should be
+// in a form that can be parsed by the math/big package
+//
+// for the DisplayFloat32 and DisplayFloat64 displays the value must be an
+// integer that fits in the width of the float. negative values are treated as
+// two's complement. for the DisplayFloat32Bits and DisplayFloat64Bits displays
+// the value is converted to the nearest float
+func (d Display) Bits(exact string) (uint64, error) {
+	exact = strings.TrimSpace(exact)
+
+	switch d {
+	case DisplayFloat32, DisplayFloat64:
+		v, ok := new(big.Int).SetString(exact, 10)
+		if !ok {
+			return 0, NotAnInteger
+		}
+		w := uint(d.Width())
+		lim := new(big.Int).Lsh(big.NewInt(1), w)
+		neg := new(big.Int).Neg(new(big.Int).Rsh(lim, 1))
+		if v.Cmp(lim) >= 0 || v.Cmp(neg) < 0 {
+			return 0, fmt.Errorf("%w: does not fit in %d bits", OutOfRange, w)
+		}
+		if v.Sign() < 0 {
+			v.Add(v, lim)
+		}
+		return v.Uint64(), nil
+
+	case DisplayFloat32Bits:
+		r, ok := new(big.Rat).SetString(exact)
+		if !ok {
+			return 0, NotANumber
+		}
+		f, _ := r.Float32()
+		return uint64(math.Float32bits(f)), nil
+
+	case DisplayFloat64Bits:
+		r, ok := new(big.Rat).SetString(exact)
+		if !ok {
+			return 0, NotANumber
+		}
+		f, _ := r.Float64()
+		return math.Float64bits(f), nil
+	}
+
+	return 0, fmt.Errorf("no bit pattern for %s", d)
+}
+
+// Format the exact value according to the display. bit patterns are shown in
+// the output base if it is binary, octal or hexadecimal and in hexadecimal
+// otherwise. the exact value should be in a form that can be parsed by the
+// math/big package
+func (d Display) Format(exact string, outputBase int) (string, error) {
+	if d == DisplayValue {
+		return exact, nil
+	}
+
+	bits, err := d.Bits(exact)
+	if err != nil {
+		return "", err
+	}
+
+	switch d {
+	case DisplayFloat32:
+		f := math.Float32frombits(uint32(bits))
+		return strconv.FormatFloat(float64(f), 'g', -1, 32), nil
+	case DisplayFloat64:
+		f := math.Float64frombits(bits)
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+
+	switch outputBase {
+	case 2, 8, 16:
+	default:
+		outputBase = 16
+	}
+
+	// pad the bit pattern with leading zeroes to the full width of the float
+	digits := int(math.Ceil(float64(d.Width()) / math.Log2(float64(outputBase))))
+	s := new(big.Int).SetUint64(bits).Text(outputBase)
+	return fmt.Sprintf("%s%s", strings.Repeat("0", digits-len(s)), s), nil
+}
+
+// FloatParts is the sign, exponent and mantissa of an IEEE-754 bit pattern
+type FloatParts struct {
+	Sign     uint64
+	Exponent uint64
+	Mantissa uint64
+
+	// the exponent with the bias removed
+	Unbiased int
+}
+
+// Parts splits the bit pattern into the sign, exponent and mantissa of the
+// float. the display decides the width of the bit pattern
+func (d Display) Parts(bits uint64) FloatParts {
+	var expBits, mantBits int
+	switch d.Width() {
+	case 32:
+		expBits, mantBits = 8, 23
+	case 64:
+		expBits, mantBits = 11, 52
+	default:
+		return FloatParts{}
+	}
+
+	var p FloatParts
+	p.Sign = bits >> (expBits + mantBits)
+	p.Exponent = (bits >> mantBits) & ((1 << expBits) - 1)
+	p.Mantissa = bits & ((1 << mantBits) - 1)
+	p.Unbiased = int(p.Exponent) - ((1 << (expBits - 1)) - 1)
+	return p
+}
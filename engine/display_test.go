@@ -0,0 +1,94 @@
+package engine_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("%v is an unexpected error", err)
+	}
+}
+
+func TestDisplayFromBits(t *testing.T) {
+	type test struct {
+		display engine.Display
+		exact   string
+		result  string
+		err     error
+	}
+
+	tests := []test{
+		{display: engine.DisplayFloat32, exact: "1065353216", result: "1"},
+		{display: engine.DisplayFloat32, exact: "3225419776", result: "-3"},
+		{display: engine.DisplayFloat32, exact: "2139095040", result: "+Inf"},
+		{display: engine.DisplayFloat32, exact: "-1082130432", result: "-1"},
+		{display: engine.DisplayFloat64, exact: "4607182418800017408", result: "1"},
+		{display: engine.DisplayFloat64, exact: "4614253070214989087", result: "3.14"},
+
+		// errors
+		{display: engine.DisplayFloat32, exact: "1.5", err: engine.NotAnInteger},
+		{display: engine.DisplayFloat32, exact: "4294967296", err: engine.OutOfRange},
+		{display: engine.DisplayFloat32, exact: "-2147483649", err: engine.OutOfRange},
+	}
+
+	for _, tst := range tests {
+		r, err := tst.display.Format(tst.exact, 10)
+		ExpectedError(t, err, tst.err)
+		ExpectEquality(t, r, tst.result)
+	}
+}
+
+func TestDisplayAsBits(t *testing.T) {
+	type test struct {
+		display engine.Display
+		exact   string
+		base    int
+		result  string
+		err     error
+	}
+
+	tests := []test{
+		{display: engine.DisplayFloat32Bits, exact: "1", base: 16, result: "3f800000"},
+		{display: engine.DisplayFloat32Bits, exact: "-0.5", base: 16, result: "bf000000"},
+		{display: engine.DisplayFloat32Bits, exact: "1/4", base: 16, result: "3e800000"},
+		{display: engine.DisplayFloat32Bits, exact: "1", base: 10, result: "3f800000"},
+		{display: engine.DisplayFloat32Bits, exact: "2", base: 2, result: "01000000000000000000000000000000"},
+		{display: engine.DisplayFloat64Bits, exact: "1", base: 16, result: "3ff0000000000000"},
+		{display: engine.DisplayFloat64Bits, exact: "3.14", base: 16, result: "40091eb851eb851f"},
+
+		// errors
+		{display: engine.DisplayFloat32Bits, exact: "1j2", base: 16, err: engine.NotANumber},
+	}
+
+	for _, tst := range tests {
+		r, err := tst.display.Format(tst.exact, tst.base)
+		ExpectedError(t, err, tst.err)
+		ExpectEquality(t, r, tst.result)
+	}
+}
+
+func TestDisplayParts(t *testing.T) {
+	p := engine.DisplayFloat32Bits.Parts(0xc0400000)
+	ExpectEquality(t, p.Sign, 1)
+	ExpectEquality(t, p.Exponent, 128)
+	ExpectEquality(t, p.Unbiased, 1)
+	ExpectEquality(t, p.Mantissa, 0x400000)
+
+	p = engine.DisplayFloat64Bits.Parts(0x3ff0000000000000)
+	ExpectEquality(t, p.Sign, 0)
+	ExpectEquality(t, p.Exponent, 1023)
+	ExpectEquality(t, p.Unbiased, 0)
+	ExpectEquality(t, p.Mantissa, 0)
+}
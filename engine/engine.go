@@ -2,9 +2,30 @@ package engine
 
 type Interface interface {
 	Execute(ref string, ex string) (string, error)
-	SetBase(Base)
+	Evaluate(ex string) (string, error)
+	SetBase(Base) error
 	Base() Base
+	ValidateBase(Base) error
 	WithErrorSupression(with func())
-	WithNumberBase(base Base, with func())
+	WithNumberBase(base Base, with func()) error
+	SetSettings(Settings) error
+	Settings() Settings
+	ValidateSettings(Settings) error
+	WithSettings(settings Settings, with func()) error
+	SetSeed(seed int64) error
 	Shape(ref string) string
+	ExactValue(ref string) (string, error)
+	Operators() []Operator
+	Names() []string
+	Tokens(ex string) []Token
+	ValueType(ref string) string
+}
+
+// Operator is a built-in operator of the engine. an operator can have a unary
+// form, a binary form or both. the description of a form is empty if the
+// operator does not have that form
+type Operator struct {
+	Name   string
+	Unary  string
+	Binary string
 }
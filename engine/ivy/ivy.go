@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 
 	"github.com/jetsetilly/ivycel/engine"
@@ -35,6 +36,11 @@ type Ivy struct {
 	// default base for spreadsheet cells
 	currBase engine.Base
 
+	// settings and currSettings work in the same way as the base and currBase
+	// fields
+	settings     engine.Settings
+	currSettings engine.Settings
+
 	errorSuppression bool
 	lastErr          error
 }
@@ -49,6 +55,7 @@ func New() Ivy {
 
 	iv.context = exec.NewContext(&iv.conf)
 	iv.SetBase(engine.Base{Input: 10, Output: 10})
+	iv.SetSettings(defaultSettings)
 
 	return iv
 }
@@ -90,13 +97,18 @@ func (iv *Ivy) WithErrorSupression(with func()) {
 	iv.errorSuppression = errorSuppression
 }
 
-// run the supplied function but with the error suppression flag set
-func (iv *Ivy) WithNumberBase(base engine.Base, with func()) {
+// run the supplied function with the number base set. the function will not be
+// run if the base can't be set
+func (iv *Ivy) WithNumberBase(base engine.Base, with func()) error {
 	// calls to WithNumberBase() may be nested
 	currBase := iv.currBase
-	iv.setBase(base)
+	err := iv.setBase(base)
+	if err != nil {
+		return iv.logError(err)
+	}
 	with()
-	iv.setBase(currBase)
+	_ = iv.setBase(currBase)
+	return nil
 }
 
 func (iv *Ivy) execute(ex string) (string, error) {
@@ -163,6 +175,28 @@ func (iv *Ivy) Execute(ref string, ex string) (string, error) {
 	return result, nil
 }
 
+// evaluate expression without assigning the result to a cell. cell references
+// in the expression should be wrapped
+func (iv *Ivy) Evaluate(ex string) (string, error) {
+	_, ex = references.CellToEngineReference("", ex)
+
+	ex = strings.TrimSpace(ex)
+	if ex == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(ex, ")") {
+		return "", iv.logError(errors.New("special commands not supported"))
+	}
+
+	result, err := iv.execute(ex)
+	if err != nil {
+		return "", iv.logError(iv.tidyError(err))
+	}
+
+	return result, nil
+}
+
 // shape of the value at the supplied reference. ref should not be wrapped
 func (iv *Ivy) Shape(ref string) string {
 	ref, _ = references.CellToEngineReference(ref, "")
@@ -180,27 +214,347 @@ func (iv *Ivy) Shape(ref string) string {
 	return shp
 }
 
-func (iv *Ivy) setBase(base engine.Base) {
+// the format used to print floating-point values when the exact value is
+// required. the precision is large enough to cover any float64 value
+const exactFloatFormat = "%.40g"
+
+// run the supplied function with the format set for printing values. an empty
+// format is the ivy default
+func (iv *Ivy) withFormat(format string, with func()) error {
+	var err error
+	iv.WithErrorSupression(func() {
+		_, err = iv.execute(fmt.Sprintf(")format %q", format))
+	})
+	if err != nil {
+		return err
+	}
+	with()
+	iv.WithErrorSupression(func() {
+		_, _ = iv.execute(fmt.Sprintf(")format %q", iv.currSettings.Format))
+	})
+	return nil
+}
+
+// the value at the supplied reference in decimal and in a form that can be
+// parsed by the math/big package. ref should not be wrapped but can include an
+// index
+func (iv *Ivy) ExactValue(ref string) (string, error) {
+	ref, _ = references.CellToEngineReference(ref, "")
+
+	var r string
+	var err error
+
+	baseErr := iv.WithNumberBase(engine.Base{Input: 10, Output: 10}, func() {
+		r, err = iv.execute(ref)
+		if err != nil {
+			return
+		}
+
+		// integers and rationals are printed exactly. floating-point values
+		// need to be printed again with a format that shows enough digits
+		if strings.ContainsAny(r, ".e") {
+			fmtErr := iv.withFormat(exactFloatFormat, func() {
+				r, err = iv.execute(ref)
+			})
+			if fmtErr != nil {
+				err = fmtErr
+			}
+		}
+	})
+	if baseErr != nil {
+		return "", baseErr
+	}
+	if err != nil {
+		return "", iv.logError(iv.tidyError(err))
+	}
+
+	return strings.TrimSpace(r), nil
+}
+
+// set the base in ivy. if the base is not accepted by ivy then the previous
+// base is restored and the error returned
+func (iv *Ivy) setBase(base engine.Base) error {
+	// ivy accepts bases that can't be printed by the rest of the program so
+	// the base is checked before it is given to ivy
+	err := base.Check()
+	if err != nil {
+		return err
+	}
+
 	iv.WithErrorSupression(func() {
-		_, err := iv.execute(fmt.Sprintf(")ibase %d", base.Input))
+		_, err = iv.execute(fmt.Sprintf(")ibase %d", base.Input))
 		if err != nil {
-			log.Printf("ivy: setBase: %s", iv.tidyError(err))
+			err = fmt.Errorf("input base %d: %w", base.Input, iv.tidyError(err))
+			return
 		}
 
 		_, err = iv.execute(fmt.Sprintf(")obase %d", base.Output))
 		if err != nil {
-			log.Printf("ivy: setBase: %s", iv.tidyError(err))
+			err = fmt.Errorf("output base %d: %w", base.Output, iv.tidyError(err))
+			return
 		}
 	})
 
+	if err != nil {
+		// the first part of the base change may have succeeded so make sure
+		// ivy is returned to the last known good base
+		if base != iv.currBase {
+			_ = iv.setBase(iv.currBase)
+		}
+		return err
+	}
+
 	iv.currBase = base
+	return nil
+}
+
+// ValidateBase checks that the base is acceptable to ivy. the current base is
+// unchanged
+func (iv *Ivy) ValidateBase(base engine.Base) error {
+	currBase := iv.currBase
+	err := iv.setBase(base)
+	if err != nil {
+		return iv.logError(err)
+	}
+	_ = iv.setBase(currBase)
+	return nil
 }
 
-func (iv *Ivy) SetBase(base engine.Base) {
+func (iv *Ivy) SetBase(base engine.Base) error {
+	err := iv.setBase(base)
+	if err != nil {
+		return iv.logError(err)
+	}
 	iv.base = base
-	iv.setBase(base)
+	return nil
 }
 
 func (iv Ivy) Base() engine.Base {
 	return iv.base
 }
+
+// the settings used by ivy when it starts
+var defaultSettings = engine.Settings{
+	Precision: 256,
+	MaxDigits: 10000,
+	Origin:    engine.OriginOne,
+}
+
+// set the precision, format, maximum digits and origin in ivy. any unset field
+// in the settings is set to the ivy default. if the settings are not accepted
+// by ivy then the previous settings are restored and the error returned
+func (iv *Ivy) setSettings(settings engine.Settings) error {
+	settings = settings.Resolve(defaultSettings)
+	if settings == iv.currSettings {
+		return nil
+	}
+
+	var err error
+
+	iv.WithErrorSupression(func() {
+		for _, cmd := range []struct {
+			name string
+			ex   string
+		}{
+			{name: "precision", ex: fmt.Sprintf(")prec %d", settings.Precision)},
+			{name: "format", ex: fmt.Sprintf(")format %q", settings.Format)},
+			{name: "maximum digits", ex: fmt.Sprintf(")maxdigits %d", settings.MaxDigits)},
+			{name: "origin", ex: fmt.Sprintf(")origin %d", settings.Origin.Index())},
+		} {
+			_, err = iv.execute(cmd.ex)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", cmd.name, iv.tidyError(err))
+				return
+			}
+		}
+	})
+
+	if err != nil {
+		// some of the settings may have been changed so make sure ivy is
+		// returned to the last known good settings. the current settings are
+		// cleared so that they are all applied again
+		curr := iv.currSettings
+		iv.currSettings = engine.Settings{}
+		_ = iv.setSettings(curr)
+		return err
+	}
+
+	iv.currSettings = settings
+	return nil
+}
+
+// run the supplied function with the settings applied. the function will not
+// be run if the settings can't be applied
+func (iv *Ivy) WithSettings(settings engine.Settings, with func()) error {
+	// calls to WithSettings() may be nested
+	currSettings := iv.currSettings
+	err := iv.setSettings(settings)
+	if err != nil {
+		return iv.logError(err)
+	}
+	with()
+	_ = iv.setSettings(currSettings)
+	return nil
+}
+
+// ValidateSettings checks that the settings are acceptable to ivy. the current
+// settings are unchanged
+func (iv *Ivy) ValidateSettings(settings engine.Settings) error {
+	currSettings := iv.currSettings
+	err := iv.setSettings(settings)
+	if err != nil {
+		return iv.logError(err)
+	}
+	_ = iv.setSettings(currSettings)
+	return nil
+}
+
+func (iv *Ivy) SetSettings(settings engine.Settings) error {
+	err := iv.setSettings(settings)
+	if err != nil {
+		return iv.logError(err)
+	}
+	iv.settings = settings.Resolve(defaultSettings)
+	return nil
+}
+
+func (iv Ivy) Settings() engine.Settings {
+	return iv.settings
+}
+
+// SetSeed seeds the random number generator used by the ? operator
+func (iv *Ivy) SetSeed(seed int64) error {
+	var err error
+	baseErr := iv.WithNumberBase(engine.Base{Input: 10, Output: 10}, func() {
+		iv.WithErrorSupression(func() {
+			_, err = iv.execute(fmt.Sprintf(")seed %d", seed))
+		})
+	})
+	if baseErr != nil {
+		return baseErr
+	}
+	if err != nil {
+		return iv.logError(iv.tidyError(err))
+	}
+	return nil
+}
+
+// Names returns the names of variables that have been created by the user.
+// names beginning with an underscore, which includes the variables used for
+// cells, are not included
+func (iv *Ivy) Names() []string {
+	ctx, ok := iv.context.(*exec.Context)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for n := range ctx.Globals {
+		if !strings.HasPrefix(n, "_") {
+			names = append(names, n)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Tokens splits the expression into tokens using the ivy scanner. cell
+// references in the expression should be wrapped
+func (iv *Ivy) Tokens(ex string) []engine.Token {
+	// converting cell references to the engine form doesn't change the length
+	// of the reference so the position of tokens in the converted expression
+	// are the same as in the original expression
+	_, conv := references.CellToEngineReference("", ex)
+
+	var toks []engine.Token
+	var pos int
+
+	scanner := scan.New(iv.context, contextName, strings.NewReader(conv))
+	for {
+		tok := scanner.Next()
+		switch tok.Type {
+		case scan.EOF:
+			return toks
+		case scan.Error:
+			// the text of an error token is the error message and not the
+			// text of the expression. the remainder of the expression is
+			// considered to be in error
+			return append(toks, engine.Token{Kind: engine.TokenError, Start: pos, End: len(conv)})
+		}
+
+		i := strings.Index(conv[pos:], tok.Text)
+		if tok.Text == "" || i < 0 {
+			continue
+		}
+		start := pos + i
+		pos = start + len(tok.Text)
+		toks = append(toks, engine.Token{Kind: tokenKind(tok), Start: start, End: pos})
+	}
+}
+
+func tokenKind(tok scan.Token) engine.TokenKind {
+	switch tok.Type {
+	case scan.Number, scan.Rational, scan.Complex:
+		return engine.TokenNumber
+	case scan.Operator, scan.Op, scan.Assign:
+		return engine.TokenOperator
+	case scan.String, scan.Char:
+		return engine.TokenString
+	case scan.Identifier:
+		if strings.HasPrefix(tok.Text, references.EngineReferencePrefix) {
+			return engine.TokenReference
+		}
+		return engine.TokenName
+	}
+	return engine.TokenOther
+}
+
+// ValueType returns the name of the type of the value at the supplied
+// reference. the types of the elements of vectors and matrices are also
+// named. ref should not be wrapped
+func (iv *Ivy) ValueType(ref string) string {
+	ref, _ = references.CellToEngineReference(ref, "")
+	v := iv.context.Lookup(ref)
+	if v == nil {
+		return "undefined"
+	}
+	return valueType(v)
+}
+
+func valueType(v value.Value) string {
+	switch v := v.(type) {
+	case value.Int:
+		return "int"
+	case value.BigInt:
+		return "big int"
+	case value.BigRat:
+		return "rational"
+	case value.BigFloat:
+		return "float"
+	case value.Complex:
+		return "complex"
+	case value.Char:
+		return "char"
+	case value.Vector:
+		return fmt.Sprintf("vector of %s", elementTypes(v))
+	case *value.Matrix:
+		return fmt.Sprintf("matrix of %s", elementTypes(v.Data()))
+	}
+	return "unknown"
+}
+
+// the distinct types of the elements in the vector in the order that they
+// are first found
+func elementTypes(vec value.Vector) string {
+	var types []string
+	for _, e := range vec {
+		t := valueType(e)
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return "nothing"
+	}
+	return strings.Join(types, ", ")
+}
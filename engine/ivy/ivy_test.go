@@ -0,0 +1,81 @@
+package ivy_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/engine/ivy"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("%v is an unexpected error", err)
+	}
+}
+
+func TestBase(t *testing.T) {
+	iv := ivy.New()
+	ExpectEquality(t, iv.Base(), engine.Base{Input: 10, Output: 10})
+
+	ExpectEquality(t, iv.SetBase(engine.Base{Input: 3, Output: 36}), nil)
+	ExpectEquality(t, iv.Base(), engine.Base{Input: 3, Output: 36})
+
+	// ivy accepts a base of zero but nothing else in the program can use it
+	ExpectedError(t, iv.SetBase(engine.Base{Input: 0, Output: 10}), engine.InvalidBase)
+	ExpectedError(t, iv.SetBase(engine.Base{Input: 10, Output: 1}), engine.InvalidBase)
+	ExpectedError(t, iv.ValidateBase(engine.Base{Input: 37, Output: 10}), engine.InvalidBase)
+	ExpectEquality(t, iv.Base(), engine.Base{Input: 3, Output: 36})
+
+	err := iv.WithNumberBase(engine.Base{Input: 10, Output: 0}, func() {})
+	ExpectedError(t, err, engine.InvalidBase)
+}
+
+func TestShape(t *testing.T) {
+	iv := ivy.New()
+	_, err := iv.Execute("A1", "5")
+	ExpectEquality(t, err, nil)
+	_, err = iv.Execute("A2", "1 2 3")
+	ExpectEquality(t, err, nil)
+	_, err = iv.Execute("A3", "2 3 rho iota 6")
+	ExpectEquality(t, err, nil)
+
+	// a scalar has no shape
+	ExpectEquality(t, iv.Shape("A1"), "")
+	ExpectEquality(t, iv.Shape("A2"), "3")
+	ExpectEquality(t, iv.Shape("A3"), "2 3")
+}
+
+func TestValueType(t *testing.T) {
+	iv := ivy.New()
+	tests := []struct {
+		ex  string
+		typ string
+	}{
+		{ex: "5", typ: "int"},
+		{ex: "2**100", typ: "big int"},
+		{ex: "1/3", typ: "rational"},
+		{ex: "sqrt 2", typ: "float"},
+		{ex: "1j2", typ: "complex"},
+		{ex: "'a'", typ: "char"},
+		{ex: "1 2 3", typ: "vector of int"},
+		{ex: "1 (1/3) 2", typ: "vector of int, rational"},
+		{ex: "2 2 rho 'abcd'", typ: "matrix of char"},
+	}
+
+	for _, tst := range tests {
+		_, err := iv.Execute("A1", tst.ex)
+		ExpectEquality(t, err, nil)
+		ExpectEquality(t, iv.ValueType("A1"), tst.typ)
+	}
+
+	ExpectEquality(t, iv.ValueType("B1"), "undefined")
+}
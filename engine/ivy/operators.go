@@ -0,0 +1,68 @@
+package ivy
+
+import "github.com/jetsetilly/ivycel/engine"
+
+// the named operators of ivy. operators that are made of symbols only are not
+// included because there is no benefit in offering them as completions
+var operators = []engine.Operator{
+	{Name: "abs", Unary: "absolute value"},
+	{Name: "acos", Unary: "arccosine"},
+	{Name: "acosh", Unary: "inverse hyperbolic cosine"},
+	{Name: "and", Binary: "logical and"},
+	{Name: "asin", Unary: "arcsine"},
+	{Name: "asinh", Unary: "inverse hyperbolic sine"},
+	{Name: "atan", Unary: "arctangent"},
+	{Name: "atanh", Unary: "inverse hyperbolic tangent"},
+	{Name: "ceil", Unary: "ceiling"},
+	{Name: "char", Unary: "character with code point"},
+	{Name: "code", Unary: "code point of character"},
+	{Name: "cos", Unary: "cosine"},
+	{Name: "cosh", Unary: "hyperbolic cosine"},
+	{Name: "decode", Binary: "value of digits in base"},
+	{Name: "div", Binary: "euclidean quotient"},
+	{Name: "down", Unary: "indices that sort descending"},
+	{Name: "drop", Binary: "remove elements from start"},
+	{Name: "encode", Binary: "digits of value in base"},
+	{Name: "fill", Binary: "expand with zeros"},
+	{Name: "flip", Unary: "reverse along first axis", Binary: "rotate along first axis"},
+	{Name: "float", Unary: "convert to floating-point"},
+	{Name: "floor", Unary: "floor"},
+	{Name: "idiv", Binary: "integer quotient"},
+	{Name: "imag", Unary: "imaginary part"},
+	{Name: "imod", Binary: "integer remainder"},
+	{Name: "in", Binary: "membership"},
+	{Name: "intersect", Binary: "elements in both"},
+	{Name: "iota", Unary: "integers 1 to n", Binary: "index of"},
+	{Name: "ivy", Unary: "evaluate text"},
+	{Name: "log", Unary: "natural logarithm", Binary: "logarithm in base"},
+	{Name: "max", Binary: "maximum"},
+	{Name: "min", Binary: "minimum"},
+	{Name: "mod", Binary: "euclidean remainder"},
+	{Name: "nand", Binary: "logical nand"},
+	{Name: "nor", Binary: "logical nor"},
+	{Name: "not", Unary: "logical not"},
+	{Name: "or", Binary: "logical or"},
+	{Name: "phase", Unary: "phase of complex number"},
+	{Name: "real", Unary: "real part"},
+	{Name: "rho", Unary: "shape", Binary: "reshape"},
+	{Name: "rot", Unary: "reverse along last axis", Binary: "rotate along last axis"},
+	{Name: "sel", Binary: "replicate elements"},
+	{Name: "sgn", Unary: "sign"},
+	{Name: "sin", Unary: "sine"},
+	{Name: "sinh", Unary: "hyperbolic sine"},
+	{Name: "sqrt", Unary: "square root"},
+	{Name: "take", Binary: "elements from start"},
+	{Name: "tan", Unary: "tangent"},
+	{Name: "tanh", Unary: "hyperbolic tangent"},
+	{Name: "text", Unary: "convert to text", Binary: "format with verb"},
+	{Name: "transp", Unary: "transpose", Binary: "transpose by axes"},
+	{Name: "union", Binary: "elements in either"},
+	{Name: "unique", Unary: "unique elements"},
+	{Name: "up", Unary: "indices that sort ascending"},
+	{Name: "xor", Binary: "logical exclusive or"},
+}
+
+// Operators returns the named operators of ivy
+func (iv *Ivy) Operators() []engine.Operator {
+	return operators
+}
@@ -0,0 +1,68 @@
+package engine
+
+// Origin is the index of the first element of a vector or matrix
+type Origin int
+
+const (
+	// the origin of the engine is used
+	OriginDefault Origin = iota
+
+	OriginZero
+	OriginOne
+)
+
+func (o Origin) String() string {
+	switch o {
+	case OriginDefault:
+		return "Default"
+	case OriginZero:
+		return "0"
+	case OriginOne:
+		return "1"
+	}
+	panic("unknown origin")
+}
+
+// Index returns the index of the first element for the origin. the default
+// origin is one
+func (o Origin) Index() int {
+	if o == OriginZero {
+		return 0
+	}
+	return 1
+}
+
+// Settings control how values are computed and printed by the engine. they
+// are applied alongside the number base
+type Settings struct {
+	// precision of floating-point values in bits
+	Precision int
+
+	// printf style format used to print values. an empty string means that
+	// the engine decides how to print values
+	Format string
+
+	// the maximum number of digits in an integer before it is printed in
+	// floating-point notation
+	MaxDigits int
+
+	Origin Origin
+}
+
+// Resolve returns an instance of the Settings type where any unset (zero)
+// field has been replaced by the corresponding field in the default settings
+func (s Settings) Resolve(def Settings) Settings {
+	if s.Precision == 0 {
+		s.Precision = def.Precision
+	}
+	if s.Format == "" {
+		s.Format = def.Format
+	}
+	if s.MaxDigits == 0 {
+		s.MaxDigits = def.MaxDigits
+	}
+	if s.Origin == OriginDefault {
+		s.Origin = def.Origin
+	}
+	return s
+}
@@ -0,0 +1,26 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func TestSettingsResolve(t *testing.T) {
+	def := engine.Settings{Precision: 256, MaxDigits: 10000, Origin: engine.OriginOne}
+
+	s := engine.Settings{Format: "%.2f", Origin: engine.OriginZero}.Resolve(def)
+	ExpectEquality(t, s.Precision, 256)
+	ExpectEquality(t, s.Format, "%.2f")
+	ExpectEquality(t, s.MaxDigits, 10000)
+	ExpectEquality(t, s.Origin, engine.OriginZero)
+
+	s = engine.Settings{}.Resolve(def)
+	ExpectEquality(t, s, def)
+}
+
+func TestOrigin(t *testing.T) {
+	ExpectEquality(t, engine.OriginDefault.Index(), 1)
+	ExpectEquality(t, engine.OriginZero.Index(), 0)
+	ExpectEquality(t, engine.OriginOne.Index(), 1)
+}
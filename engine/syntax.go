@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TokenKind is the syntactic category of a token
+type TokenKind int
+
+// list of valid token kinds
+const (
+	TokenOther TokenKind = iota
+	TokenNumber
+	TokenOperator
+	TokenName
+	TokenString
+	TokenReference
+	TokenError
+)
+
+// Token is a part of an expression. the start and end of the token are byte
+// offsets into the expression
+type Token struct {
+	Kind  TokenKind
+	Start int
+	End   int
+}
+
+// match the first quoted text in an error message
+var quotedText = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// ErrorSpan returns the part of the entry that the error refers to. engines
+// are expected to quote the offending text in their error messages. the error
+// messages don't say where the text is so the error is only located if the
+// quoted text appears once in the entry. an error that refers to the end of the
+// input is located at the final character of the entry. the ok value is false
+// if the error can't be located
+func ErrorSpan(entry string, err error) (start int, end int, ok bool) {
+	if err == nil {
+		return 0, 0, false
+	}
+	msg := err.Error()
+
+	if q := quotedText.FindString(msg); q != "" {
+		s, uqErr := strconv.Unquote(q)
+		if uqErr == nil && strings.TrimSpace(s) != "" {
+			if strings.Count(entry, s) == 1 {
+				i := strings.Index(entry, s)
+				return i, i + len(s), true
+			}
+		}
+	}
+
+	if strings.Contains(msg, "EOF") {
+		entry = strings.TrimRight(entry, " \t")
+		if len(entry) > 0 {
+			return len(entry) - 1, len(entry), true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// NameUsage returns the names that are assigned to in the expression and the
+// names that are used in the expression. the tokens should be the result of
+// calling Tokens() for the same expression. a name is only returned once in
+// each list
+func NameUsage(ex string, toks []Token) (assigned []string, used []string) {
+	for i, t := range toks {
+		if t.Kind != TokenName {
+			continue
+		}
+		n := ex[t.Start:t.End]
+		if i+1 < len(toks) && toks[i+1].Kind == TokenOperator && ex[toks[i+1].Start:toks[i+1].End] == "=" {
+			if !slices.Contains(assigned, n) {
+				assigned = append(assigned, n)
+			}
+		} else if !slices.Contains(used, n) {
+			used = append(used, n)
+		}
+	}
+	return assigned, used
+}
+
+// UsesRandom returns true if the expression uses the random operator. the
+// tokens should be the result of calling Tokens() for the same expression
+func UsesRandom(ex string, toks []Token) bool {
+	for _, t := range toks {
+		if t.Kind == TokenOperator && ex[t.Start:t.End] == "?" {
+			return true
+		}
+	}
+	return false
+}
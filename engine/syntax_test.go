@@ -0,0 +1,89 @@
+package engine_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+func TestErrorSpan(t *testing.T) {
+	type test struct {
+		entry string
+		err   error
+		ok    bool
+		start int
+		end   int
+	}
+
+	var testingTable = []test{
+		{entry: "1 + x", err: errors.New(`undefined variable "x"`), ok: true, start: 4, end: 5},
+		{entry: "{A1} + {B2}", err: errors.New(`undefined variable "{B2}"`), ok: true, start: 7, end: 11},
+		{entry: "2 * (3 + ", err: errors.New("unexpected EOF"), ok: true, start: 7, end: 8},
+		{entry: "1 + 2", err: errors.New(`bad "thing"`), ok: false},
+		{entry: "x + max x", err: errors.New(`undefined variable "x"`), ok: false},
+		{entry: "1 + 2", err: errors.New("division by zero"), ok: false},
+		{entry: "1 + 2", err: nil, ok: false},
+	}
+
+	for _, tst := range testingTable {
+		start, end, ok := engine.ErrorSpan(tst.entry, tst.err)
+		ExpectEquality(t, ok, tst.ok)
+		if ok {
+			ExpectEquality(t, start, tst.start)
+			ExpectEquality(t, end, tst.end)
+		}
+	}
+}
+
+func TestNameUsage(t *testing.T) {
+	// tokens for "x = 3; y * x"
+	ex := "x = 3; y * x"
+	toks := []engine.Token{
+		{Kind: engine.TokenName, Start: 0, End: 1},
+		{Kind: engine.TokenOperator, Start: 2, End: 3},
+		{Kind: engine.TokenNumber, Start: 4, End: 5},
+		{Kind: engine.TokenOther, Start: 5, End: 6},
+		{Kind: engine.TokenName, Start: 7, End: 8},
+		{Kind: engine.TokenOperator, Start: 9, End: 10},
+		{Kind: engine.TokenName, Start: 11, End: 12},
+	}
+
+	assigned, used := engine.NameUsage(ex, toks)
+	ExpectEquality(t, len(assigned), 1)
+	ExpectEquality(t, assigned[0], "x")
+	ExpectEquality(t, len(used), 2)
+	ExpectEquality(t, used[0], "y")
+	ExpectEquality(t, used[1], "x")
+
+	// comparison is not assignment
+	ex = "x == 3"
+	toks = []engine.Token{
+		{Kind: engine.TokenName, Start: 0, End: 1},
+		{Kind: engine.TokenOperator, Start: 2, End: 4},
+		{Kind: engine.TokenNumber, Start: 5, End: 6},
+	}
+	assigned, used = engine.NameUsage(ex, toks)
+	ExpectEquality(t, len(assigned), 0)
+	ExpectEquality(t, len(used), 1)
+}
+
+func TestUsesRandom(t *testing.T) {
+	// tokens for "?6 + x"
+	ex := "?6 + x"
+	toks := []engine.Token{
+		{Kind: engine.TokenOperator, Start: 0, End: 1},
+		{Kind: engine.TokenNumber, Start: 1, End: 2},
+		{Kind: engine.TokenOperator, Start: 3, End: 4},
+		{Kind: engine.TokenName, Start: 5, End: 6},
+	}
+	ExpectEquality(t, engine.UsesRandom(ex, toks), true)
+	ExpectEquality(t, engine.UsesRandom(ex, toks[1:]), false)
+
+	// a question mark in a string is not the random operator
+	ex = "'?'"
+	toks = []engine.Token{
+		{Kind: engine.TokenString, Start: 0, End: 3},
+	}
+	ExpectEquality(t, engine.UsesRandom(ex, toks), false)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/binaryfile"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+// element widths offered by the export dialog
+var exportWidths = []struct {
+	label string
+	width int
+}{
+	{label: "8 bit", width: 1},
+	{label: "16 bit", width: 2},
+	{label: "32 bit", width: 4},
+	{label: "64 bit", width: 8},
+}
+
+// state of the export dialog
+type export struct {
+	rng      string
+	format   int32
+	width    int32
+	order    engine.ByteOrder
+	name     string
+	address  int32
+	filename string
+	err      error
+
+	// cells that can't be exported. nothing is written while there are
+	// problems
+	problems []string
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the export dialog writes the integer results in a range of cells to a file
+func (iv *ivycel) exportModal() giu.Widget {
+	const popupName = "Export Range"
+
+	return giu.Custom(func() {
+		if !iv.export.active {
+			return
+		}
+
+		if iv.export.open {
+			iv.export.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		ex := &iv.export
+
+		var formats []string
+		for _, f := range binaryfile.Formats {
+			formats = append(formats, f.String())
+		}
+
+		var widths []string
+		for _, w := range exportWidths {
+			widths = append(widths, w.label)
+		}
+
+		// options that only make sense for some formats
+		var options giu.Widget
+		switch binaryfile.Formats[ex.format] {
+		case binaryfile.FormatC, binaryfile.FormatGo:
+			options = giu.Column(
+				giu.Label("Variable name"),
+				giu.InputText(&ex.name).Size(200),
+			)
+		case binaryfile.FormatIntelHex, binaryfile.FormatSRecord:
+			options = giu.Column(
+				giu.Label("Load address"),
+				giu.InputInt(&ex.address).Size(100),
+			)
+		default:
+			options = giu.Custom(func() {})
+		}
+
+		var problems giu.Widget
+		if len(ex.problems) > 0 {
+			var lbls []giu.Widget
+			for _, p := range ex.problems {
+				lbls = append(lbls, giu.Label(p))
+			}
+			problems = giu.Column(
+				giu.Label(fmt.Sprintf("%d cells can't be exported", len(ex.problems))),
+				giu.Child().Size(400, 100).Layout(lbls...),
+			)
+		} else {
+			problems = giu.Custom(func() {})
+		}
+
+		var errLabel giu.Widget
+		if ex.err != nil {
+			errLabel = giu.Label(ex.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label("Range (eg. A1:D4)"),
+			giu.InputText(&ex.rng).Size(100),
+			giu.Combo("Format", formats[ex.format], formats, &ex.format).Size(200),
+			giu.Combo("Element width", widths[ex.width], widths, &ex.width).Size(200),
+			giu.Row(
+				giu.RadioButton(engine.BigEndian.String(), ex.order == engine.BigEndian).OnChange(func() {
+					ex.order = engine.BigEndian
+				}),
+				giu.RadioButton(engine.LittleEndian.String(), ex.order == engine.LittleEndian).OnChange(func() {
+					ex.order = engine.LittleEndian
+				}),
+			),
+			options,
+			giu.Label("Filename"),
+			giu.InputText(&ex.filename).Size(300),
+			problems,
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					ex.problems = ex.problems[:0]
+					ex.err = iv.exportRange()
+					if ex.err != nil || len(ex.problems) > 0 {
+						return
+					}
+					ex.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					ex.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// export the range described by the export dialog. any cells that can't be
+// exported are added to the problems list and nothing is written
+func (iv *ivycel) exportRange() error {
+	ex := &iv.export
+
+	rng, err := cells.RangeFromReference(ex.rng)
+	if err != nil {
+		return err
+	}
+
+	rows, columns := iv.worksheet.Size()
+	if rng.End.Row >= rows || rng.End.Column >= columns {
+		return fmt.Errorf("%s is outside the worksheet", rng.Reference())
+	}
+
+	if ex.filename == "" {
+		return errors.New("no filename")
+	}
+
+	// the load address is an unsigned value in the Intel HEX and S-record
+	// formats
+	switch binaryfile.Formats[ex.format] {
+	case binaryfile.FormatIntelHex, binaryfile.FormatSRecord:
+		if ex.address < 0 {
+			return fmt.Errorf("load address %d is negative", ex.address)
+		}
+	}
+
+	width := exportWidths[ex.width].width
+
+	// collect values from the range and make sure every value can be
+	// exported before writing anything
+	var values [][]*big.Int
+	for rowi := rng.Start.Row; rowi <= rng.End.Row; rowi++ {
+		var row []*big.Int
+		for coli := rng.Start.Column; coli <= rng.End.Column; coli++ {
+			cell := iv.worksheet.Cell(rowi, coli)
+			v, err := iv.cellInteger(cell)
+			if err != nil {
+				ex.problems = append(ex.problems, err.Error())
+				continue // for loop
+			}
+			if !binaryfile.InRange(v, width) {
+				ex.problems = append(ex.problems, fmt.Sprintf("%s: %v does not fit in %d bits",
+					cell.Position().Reference(), v, width*8))
+				continue // for loop
+			}
+			row = append(row, v)
+		}
+		values = append(values, row)
+	}
+
+	if len(ex.problems) > 0 {
+		return nil
+	}
+
+	var flat []*big.Int
+	for _, row := range values {
+		flat = append(flat, row...)
+	}
+
+	var output []byte
+
+	switch binaryfile.Formats[ex.format] {
+	case binaryfile.FormatBinary:
+		output, err = binaryfile.Encode(flat, width, ex.order)
+	case binaryfile.FormatC:
+		output = []byte(binaryfile.CArray(ex.name, values, width))
+	case binaryfile.FormatGo:
+		output = []byte(binaryfile.GoSlice(ex.name, values, width))
+	case binaryfile.FormatIntelHex:
+		output, err = binaryfile.Encode(flat, width, ex.order)
+		output = []byte(binaryfile.IntelHex(output, uint32(ex.address)))
+	case binaryfile.FormatSRecord:
+		output, err = binaryfile.Encode(flat, width, ex.order)
+		output = []byte(binaryfile.SRecord(output, uint32(ex.address)))
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ex.filename, output, 0o644)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine/ivy"
+	"github.com/jetsetilly/ivycel/worksheet"
+)
+
+// state of the open and save dialogs
+type worksheetFile struct {
+	filename string
+	err      error
+
+	// the dialog saves the worksheet rather than opens a worksheet
+	save bool
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// add the user data of the main package to a new cell
+func addCellUser(cell *cells.Cell) {
+	cell.User = &cellUser{}
+}
+
+// the worksheet user data for a new worksheet
+func newWorksheetUser(ws worksheet.Worksheet) *worksheetUser {
+	return &worksheetUser{
+		selected:  ws.Cell(0, 0),
+		selection: cells.NewSelection(cells.Position{}),
+	}
+}
+
+// the file dialog opens a worksheet from a file or saves the worksheet to a
+// file
+func (iv *ivycel) fileModal() giu.Widget {
+	const popupName = "Worksheet File"
+
+	return giu.Custom(func() {
+		if !iv.file.active {
+			return
+		}
+
+		if iv.file.open {
+			iv.file.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		f := &iv.file
+
+		var errLabel giu.Widget
+		if f.err != nil {
+			errLabel = giu.Label(f.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		label := "Open"
+		if f.save {
+			label = "Save"
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label("Filename"),
+			giu.InputText(&f.filename).Size(300),
+			errLabel,
+			giu.Row(
+				giu.Button(label).OnClick(func() {
+					if f.save {
+						f.err = iv.saveWorksheet()
+					} else {
+						f.err = iv.openWorksheet()
+					}
+					if f.err != nil {
+						return
+					}
+					f.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					f.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// write the worksheet to the file named in the file dialog
+func (iv *ivycel) saveWorksheet() error {
+	if iv.file.filename == "" {
+		return errors.New("no filename")
+	}
+
+	f, err := os.OpenFile(iv.file.filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := iv.worksheet.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replace the worksheet with the worksheet in the file named in the file
+// dialog. the worksheet is loaded into a new engine so that the variables of
+// the current worksheet aren't defined in the new worksheet. nothing is changed
+// if the file can't be loaded
+func (iv *ivycel) openWorksheet() error {
+	if iv.file.filename == "" {
+		return errors.New("no filename")
+	}
+
+	f, err := os.Open(iv.file.filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	eng := ivy.New()
+	ws, err := worksheet.Load(&eng, f, addCellUser)
+	if err != nil {
+		return err
+	}
+	ws.User = newWorksheetUser(ws)
+
+	iv.ivy = &eng
+	iv.worksheet = ws
+
+	// state that refers to cells of the previous worksheet
+	iv.undoSteps = nil
+	iv.undoFailed = nil
+	iv.rejected = nil
+	iv.inspection = inspection{}
+	iv.trace = trace{}
+	iv.scenarios.summaryOutputs = nil
+	iv.scenarios.summaryScenarios = nil
+	iv.scenarios.summary = nil
+
+	return nil
+}
@@ -13,7 +13,21 @@ var FontAwesome []byte
 
 // Icon glyphs from FontAwesome
 const (
-	FileMenu = rune(0xf15b)
+	FileMenu      = rune(0xf15b)
+	WorksheetMenu = rune(0xf0ce)
+)
+
+// Icon glyphs from FontAwesome that can be shown in a cell by a conditional
+// formatting rule
+const (
+	Flag        = rune(0xf024)
+	Star        = rune(0xf005)
+	Warning     = rune(0xf071)
+	Check       = rune(0xf00c)
+	Cross       = rune(0xf00d)
+	ArrowUp     = rune(0xf062)
+	ArrowDown   = rune(0xf063)
+	CircleSolid = rune(0xf111)
 )
 
 const (
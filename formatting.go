@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/fonts"
+	"github.com/jetsetilly/ivycel/references"
+	"github.com/jetsetilly/ivycel/worksheet"
+)
+
+// the icons that can be chosen for a conditional formatting rule. the first
+// entry is no icon
+var formattingIcons = []string{
+	"",
+	string(fonts.Flag),
+	string(fonts.Star),
+	string(fonts.Warning),
+	string(fonts.Check),
+	string(fonts.Cross),
+	string(fonts.ArrowUp),
+	string(fonts.ArrowDown),
+	string(fonts.CircleSolid),
+}
+
+// state of the conditional formatting window. the fields are used to create a
+// new rule
+type formatting struct {
+	rng           string
+	condition     string
+	useBackground bool
+	background    color.RGBA
+	useText       bool
+	text          color.RGBA
+	icon          int32
+	err           error
+
+	// the conditional formatting window is open
+	active bool
+}
+
+// open the conditional formatting window with the selection as the range of
+// the new rule
+func (iv *ivycel) openFormatting() {
+	f := &iv.formatting
+	f.rng = iv.worksheet.User.(*worksheetUser).selection.Active().Reference()
+	if f.condition == "" {
+		f.condition = fmt.Sprintf("%s > 0", references.SelfReference)
+		f.useBackground = true
+		f.background = color.RGBA{R: 120, G: 40, B: 40, A: 255}
+		f.text = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	f.err = nil
+	f.active = true
+}
+
+// add a rule from the fields in the conditional formatting window
+func (iv *ivycel) addFormattingRule() error {
+	f := &iv.formatting
+
+	rng, err := cells.RangeFromReference(strings.TrimSpace(f.rng))
+	if err != nil {
+		return err
+	}
+
+	r := worksheet.Rule{
+		Range:     rng,
+		Condition: f.condition,
+		Format: worksheet.Format{
+			Icon: formattingIcons[f.icon],
+		},
+	}
+	if f.useBackground {
+		r.Format.Background = f.background
+		r.Format.Background.A = 255
+	}
+	if f.useText {
+		r.Format.Text = f.text
+		r.Format.Text.A = 255
+	}
+	if r.Format == (worksheet.Format{}) {
+		return errors.New("rule must have a colour or an icon")
+	}
+
+	return iv.worksheet.AddRule(r)
+}
+
+// the style for a cell with a conditional format. returns nil if the format
+// doesn't change the style of the cell
+func conditionalStyle(f worksheet.Format) *giu.StyleSetter {
+	if f.Background.A == 0 && f.Text.A == 0 {
+		return nil
+	}
+	sty := giu.Style()
+	if f.Background.A != 0 {
+		sty.SetColor(giu.StyleColorButton, f.Background)
+	}
+	if f.Text.A != 0 {
+		sty.SetColor(giu.StyleColorText, f.Text)
+	}
+	return sty
+}
+
+// the conditional formatting window lists the rules in order of priority and
+// allows new rules to be added
+func (iv *ivycel) formattingWindow() {
+	f := &iv.formatting
+	if !f.active {
+		return
+	}
+
+	var errLabel giu.Widget
+	if f.err != nil {
+		errLabel = giu.Label(f.err.Error())
+	} else {
+		errLabel = giu.Label("")
+	}
+
+	var rules []*giu.TableRowWidget
+	for i, r := range iv.worksheet.Rules() {
+		var format []string
+		if r.Format.Background.A != 0 {
+			format = append(format, "background")
+		}
+		if r.Format.Text.A != 0 {
+			format = append(format, "text")
+		}
+		if r.Format.Icon != "" {
+			format = append(format, r.Format.Icon)
+		}
+		rules = append(rules, giu.TableRow(
+			giu.Label(r.Range.Reference()),
+			giu.Label(r.Condition),
+			giu.Label(strings.Join(format, ", ")),
+			giu.Button(fmt.Sprintf("Delete##rule%d", i)).OnClick(func() {
+				iv.worksheet.DeleteRule(i)
+			}),
+		))
+	}
+
+	giu.Window("Conditional Formatting").IsOpen(&f.active).Size(500, 400).Layout(
+		giu.Label(fmt.Sprintf("The condition is evaluated for each cell in the range. %s refers to the cell", references.SelfReference)),
+		giu.Row(giu.Label("Range     "), giu.InputText(&f.rng).Size(150)),
+		giu.Row(giu.Label("Condition "), giu.InputText(&f.condition).Size(-1)),
+		giu.Row(
+			giu.Checkbox("Background", &f.useBackground),
+			giu.ColorEdit("##background", &f.background).Flags(giu.ColorEditFlagsNoInputs|giu.ColorEditFlagsNoAlpha),
+			giu.Checkbox("Text", &f.useText),
+			giu.ColorEdit("##text", &f.text).Flags(giu.ColorEditFlagsNoInputs|giu.ColorEditFlagsNoAlpha),
+			giu.Label("Icon"),
+			giu.Combo("##icon", formattingIcons[f.icon], formattingIcons, &f.icon).Size(50),
+		),
+		giu.Button("Add Rule").OnClick(func() {
+			f.err = iv.addFormattingRule()
+		}),
+		errLabel,
+		giu.Separator(),
+		giu.Table().
+			Flags(giu.TableFlagsBorders|giu.TableFlagsRowBg).
+			Columns(
+				giu.TableColumn("Range"),
+				giu.TableColumn("Condition"),
+				giu.TableColumn("Format"),
+				giu.TableColumn(""),
+			).
+			Rows(rules...),
+	)
+}
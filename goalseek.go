@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/goalseek"
+)
+
+// the tolerance and number of iterations used for goal seeking a
+// floating-point input
+const goalSeekTolerance = 1e-9
+const goalSeekIterations = 100
+
+// state of the goal seek dialog
+type goalSeek struct {
+	target  string
+	value   string
+	input   string
+	integer bool
+	err     error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// open the goal seek dialog with the cell as the target
+func (iv *ivycel) openGoalSeek(cell *cells.Cell) {
+	iv.goalSeek = goalSeek{
+		target:  cell.Position().Reference(),
+		input:   iv.goalSeek.input,
+		integer: iv.goalSeek.integer,
+		open:    true,
+		active:  true,
+	}
+}
+
+// the value of the cell as an exact number
+func (iv *ivycel) exactNumber(cell *cells.Cell) (*big.Rat, error) {
+	if err := cell.Error(); err != nil {
+		return nil, fmt.Errorf("{%s} is in error: %w", cell.Position().Reference(), err)
+	}
+	v, err := iv.ivy.ExactValue(cell.Position().Reference())
+	if err != nil {
+		return nil, err
+	}
+	r, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return nil, fmt.Errorf("{%s} is not a number", cell.Position().Reference())
+	}
+	return r, nil
+}
+
+// an integer in text form in the base
+func formatInteger(x *big.Int, base int) string {
+	if x.Sign() < 0 {
+		return fmt.Sprintf("-%s", engine.FormatInteger(new(big.Int).Neg(x).Text(base)))
+	}
+	return engine.FormatInteger(x.Text(base))
+}
+
+// search for the entry of the input cell that makes the value of the target
+// cell equal to the value. the value is a decimal number. if the search
+// succeeds then the entry of the input cell is changed and the change can be
+// undone. otherwise the input cell is left unchanged
+func (iv *ivycel) runGoalSeek() error {
+	gs := &iv.goalSeek
+
+	cell := func(ref string) (*cells.Cell, error) {
+		p, err := cells.PositionFromReference(strings.TrimSpace(ref))
+		if err != nil {
+			return nil, err
+		}
+		rows, columns := iv.worksheet.Size()
+		if p.Row >= rows || p.Column >= columns {
+			return nil, fmt.Errorf("{%s} is outside the worksheet", p.Reference())
+		}
+		return iv.worksheet.Cell(p.Row, p.Column), nil
+	}
+
+	target, err := cell(gs.target)
+	if err != nil {
+		return fmt.Errorf("target cell: %w", err)
+	}
+	input, err := cell(gs.input)
+	if err != nil {
+		return fmt.Errorf("input cell: %w", err)
+	}
+	if input.ReadOnly() {
+		return fmt.Errorf("input cell: {%s} is part of the result of another cell", input.Position().Reference())
+	}
+
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(gs.value))
+	if !ok {
+		return errors.New("target value must be a decimal number")
+	}
+
+	deps := iv.worksheet.DependentCells(input)
+	if target != input && !slices.Contains(deps, target) {
+		return fmt.Errorf("{%s} does not depend on {%s}", target.Position().Reference(), input.Position().Reference())
+	}
+
+	// change the input cell and recalculate the cells that depend on it. the
+	// value of the target cell is then returned
+	original := input.Entry
+	try := func(entry string) (*big.Rat, error) {
+		input.Entry = entry
+		input.Commit(true)
+		for _, d := range deps {
+			// committing a volatile cell would change its value
+			if !iv.worksheet.Volatile(d) {
+				d.Commit(false)
+			}
+		}
+		return iv.exactNumber(target)
+	}
+
+	x0, err := iv.exactNumber(input)
+	if err != nil {
+		x0 = new(big.Rat)
+	}
+
+	var result string
+	iv.ivy.WithErrorSupression(func() {
+		if gs.integer {
+			base := input.Base().Input
+			var x *big.Int
+			x, err = goalseek.Integer(func(x *big.Int) (*big.Rat, error) {
+				return try(formatInteger(x, base))
+			}, value, new(big.Int).Quo(x0.Num(), x0.Denom()))
+			if err == nil {
+				result = formatInteger(x, base)
+			}
+			return
+		}
+
+		// floating-point numbers are written in decimal so the input cell
+		// must use an input base of ten
+		if input.Base().Input != 10 {
+			err = errors.New("input cell must have an input base of 10 for a non-integer search")
+			return
+		}
+		start, _ := x0.Float64()
+		target, _ := value.Float64()
+		var x float64
+		x, err = goalseek.Float(func(x float64) (float64, error) {
+			r, err := try(strconv.FormatFloat(x, 'g', -1, 64))
+			if err != nil {
+				return 0, err
+			}
+			f, _ := r.Float64()
+			return f, nil
+		}, target, start, goalSeekTolerance, goalSeekIterations)
+		if err == nil {
+			result = strconv.FormatFloat(x, 'g', -1, 64)
+		}
+	})
+
+	if err != nil {
+		input.Entry = original
+		input.Commit(true)
+		iv.worksheet.RecalculateAll()
+		return err
+	}
+
+	input.Entry = result
+	input.Commit(true)
+	iv.worksheet.RecalculateAll()
+	iv.pushUndo("Goal Seek", map[*cells.Cell]string{input: original})
+
+	return nil
+}
+
+// the goal seek dialog finds the value of an input cell that gives a target
+// cell the chosen value
+func (iv *ivycel) goalSeekModal() giu.Widget {
+	const popupName = "Goal Seek"
+
+	return giu.Custom(func() {
+		gs := &iv.goalSeek
+		if !gs.active {
+			return
+		}
+
+		if gs.open {
+			gs.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var errLabel giu.Widget
+		if gs.err != nil {
+			errLabel = giu.Label(gs.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Row(giu.Label("Target cell          "), giu.InputText(&gs.target).Size(150)),
+			giu.Row(giu.Label("Target value (dec)   "), giu.InputText(&gs.value).Size(150)),
+			giu.Row(giu.Label("Changing input cell  "), giu.InputText(&gs.input).Size(150)),
+			giu.Checkbox("Integer search", &gs.integer),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					gs.err = iv.runGoalSeek()
+					if gs.err != nil {
+						return
+					}
+					gs.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					gs.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
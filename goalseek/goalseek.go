@@ -0,0 +1,191 @@
+// Package goalseek searches for the input value that makes a function produce
+// a target value.
+package goalseek
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+var NotFound = errors.New("no solution found")
+
+// the number of times the search interval is doubled when looking for an
+// interval that contains the solution
+const maxExpansions = 128
+
+// Float searches for x where f(x) is within the tolerance of the target. the
+// secant method is used starting from x0. if the secant method fails then
+// bisection is used on an interval around x0 that contains the solution
+func Float(f func(x float64) (float64, error), target float64, x0 float64, tolerance float64, maxIterations int) (float64, error) {
+	g := func(x float64) (float64, error) {
+		y, err := f(x)
+		if err != nil {
+			return 0, err
+		}
+		return y - target, nil
+	}
+
+	x, err := secant(g, x0, tolerance, maxIterations)
+	if err == nil {
+		return x, nil
+	}
+
+	lo, hi, err := bracketFloat(g, x0)
+	if err != nil {
+		return 0, err
+	}
+	return bisectFloat(g, lo, hi, tolerance, maxIterations)
+}
+
+func secant(g func(float64) (float64, error), x0 float64, tolerance float64, maxIterations int) (float64, error) {
+	x1 := x0 + max(math.Abs(x0)*0.01, 1)
+
+	y0, err := g(x0)
+	if err != nil {
+		return 0, err
+	}
+	if math.Abs(y0) <= tolerance {
+		return x0, nil
+	}
+
+	for range maxIterations {
+		y1, err := g(x1)
+		if err != nil {
+			return 0, err
+		}
+		if math.Abs(y1) <= tolerance {
+			return x1, nil
+		}
+		if y1 == y0 {
+			return 0, fmt.Errorf("%w: secant method stalled", NotFound)
+		}
+
+		x0, y0, x1 = x1, y1, x1-y1*(x1-x0)/(y1-y0)
+		if math.IsNaN(x1) || math.IsInf(x1, 0) {
+			return 0, fmt.Errorf("%w: secant method diverged", NotFound)
+		}
+	}
+
+	return 0, fmt.Errorf("%w: secant method did not converge", NotFound)
+}
+
+// find an interval around x0 where the sign of g() changes
+func bracketFloat(g func(float64) (float64, error), x0 float64) (float64, float64, error) {
+	y0, err := g(x0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	step := max(math.Abs(x0)*0.01, 1)
+	for range maxExpansions {
+		for _, x := range []float64{x0 - step, x0 + step} {
+			y, err := g(x)
+			if err != nil {
+				continue // for loop
+			}
+			if math.Signbit(y) != math.Signbit(y0) {
+				return min(x0, x), max(x0, x), nil
+			}
+		}
+		step *= 2
+	}
+
+	return 0, 0, fmt.Errorf("%w: no interval contains the target", NotFound)
+}
+
+func bisectFloat(g func(float64) (float64, error), lo float64, hi float64, tolerance float64, maxIterations int) (float64, error) {
+	ylo, err := g(lo)
+	if err != nil {
+		return 0, err
+	}
+
+	for range maxIterations {
+		mid := lo + (hi-lo)/2
+		y, err := g(mid)
+		if err != nil {
+			return 0, err
+		}
+		if math.Abs(y) <= tolerance {
+			return mid, nil
+		}
+		if math.Signbit(y) == math.Signbit(ylo) {
+			lo, ylo = mid, y
+		} else {
+			hi = mid
+		}
+	}
+
+	return 0, fmt.Errorf("%w: bisection did not converge", NotFound)
+}
+
+// Integer searches for an integer x where f(x) is exactly the target. an
+// interval around x0 that contains the solution is found and then searched
+// with bisection. f should be monotonic in the interval
+func Integer(f func(x *big.Int) (*big.Rat, error), target *big.Rat, x0 *big.Int) (*big.Int, error) {
+	g := func(x *big.Int) (int, error) {
+		y, err := f(x)
+		if err != nil {
+			return 0, err
+		}
+		return y.Cmp(target), nil
+	}
+
+	c0, err := g(x0)
+	if err != nil {
+		return nil, err
+	}
+	if c0 == 0 {
+		return new(big.Int).Set(x0), nil
+	}
+
+	// find an interval where the comparison with the target changes
+	var lo, hi *big.Int
+	var clo int
+	step := big.NewInt(1)
+	for i := 0; lo == nil && i < maxExpansions; i++ {
+		for _, x := range []*big.Int{new(big.Int).Sub(x0, step), new(big.Int).Add(x0, step)} {
+			c, err := g(x)
+			if err != nil {
+				continue // for loop
+			}
+			if c == 0 {
+				return x, nil
+			}
+			if c != c0 {
+				if x.Cmp(x0) < 0 {
+					lo, hi, clo = x, new(big.Int).Set(x0), c
+				} else {
+					lo, hi, clo = new(big.Int).Set(x0), x, c0
+				}
+				break // for loop
+			}
+		}
+		step.Lsh(step, 1)
+	}
+	if lo == nil {
+		return nil, fmt.Errorf("%w: no interval contains the target", NotFound)
+	}
+
+	// bisect until the interval can't be divided any further
+	one := big.NewInt(1)
+	for new(big.Int).Sub(hi, lo).Cmp(one) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		c, err := g(mid)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0 {
+			return mid, nil
+		}
+		if c == clo {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no integer gives the target exactly", NotFound)
+}
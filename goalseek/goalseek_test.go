@@ -0,0 +1,72 @@
+package goalseek_test
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/goalseek"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("expected error '%v' but got '%v'", expected, err)
+	}
+}
+
+func TestFloat(t *testing.T) {
+	square := func(x float64) (float64, error) {
+		return x * x, nil
+	}
+	x, err := goalseek.Float(square, 2, 1, 1e-9, 100)
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, math.Abs(x-math.Sqrt2) < 1e-6, true)
+
+	// the secant method stalls on a step function so bisection is used
+	step := func(x float64) (float64, error) {
+		if x < 10.5 {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	x, err = goalseek.Float(step, 1, 0, 0, 100)
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, x >= 10.5, true)
+
+	// there is no solution
+	_, err = goalseek.Float(square, -1, 1, 1e-9, 100)
+	ExpectedError(t, err, goalseek.NotFound)
+}
+
+func TestInteger(t *testing.T) {
+	double := func(x *big.Int) (*big.Rat, error) {
+		return new(big.Rat).SetInt(new(big.Int).Mul(x, big.NewInt(2))), nil
+	}
+
+	x, err := goalseek.Integer(double, big.NewRat(1000, 1), big.NewInt(0))
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, x.Int64(), 500)
+
+	x, err = goalseek.Integer(double, big.NewRat(-64, 1), big.NewInt(7))
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, x.Int64(), -32)
+
+	// large values are searched exactly
+	target, _ := new(big.Rat).SetString("36893488147419103232")
+	x, err = goalseek.Integer(double, target, big.NewInt(1))
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, x.String(), "18446744073709551616")
+
+	// no integer doubles to an odd number
+	_, err = goalseek.Integer(double, big.NewRat(7, 1), big.NewInt(0))
+	ExpectedError(t, err, goalseek.NotFound)
+}
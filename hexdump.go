@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/binaryfile"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+// element widths offered by the hex dump dialog
+var hexDumpWidths = []struct {
+	label string
+	width int
+}{
+	{label: "Byte", width: 1},
+	{label: "Halfword", width: 2},
+	{label: "Word", width: 4},
+}
+
+// state of the hex dump dialog
+type hexDump struct {
+	filename    string
+	offset      int32
+	length      int32
+	bytesPerRow int32
+	width       int32
+	order       engine.ByteOrder
+	err         error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the hex dump dialog reads a binary file into the worksheet starting at the
+// selected cell. the first column is the address of the first byte in each row
+func (iv *ivycel) hexDumpModal() giu.Widget {
+	const popupName = "Load Binary File"
+
+	return giu.Custom(func() {
+		if !iv.hexDump.active {
+			return
+		}
+
+		if iv.hexDump.open {
+			iv.hexDump.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		hd := &iv.hexDump
+
+		var errLabel giu.Widget
+		if hd.err != nil {
+			errLabel = giu.Label(hd.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		var widths []string
+		for _, w := range hexDumpWidths {
+			widths = append(widths, w.label)
+		}
+
+		at := iv.worksheet.User.(*worksheetUser).selected.Position()
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label(fmt.Sprintf("Load binary file into worksheet at %s", at.Reference())),
+			giu.Label("Filename"),
+			giu.InputText(&hd.filename).Size(300),
+			giu.Label("Offset"),
+			giu.InputInt(&hd.offset).Size(100),
+			giu.Label("Length (zero for the entire file)"),
+			giu.InputInt(&hd.length).Size(100),
+			giu.Label("Bytes per row"),
+			giu.InputInt(&hd.bytesPerRow).Size(100),
+			giu.Combo("Granularity", widths[hd.width], widths, &hd.width).Size(100),
+			giu.Row(
+				giu.RadioButton(engine.BigEndian.String(), hd.order == engine.BigEndian).OnChange(func() {
+					hd.order = engine.BigEndian
+				}),
+				giu.RadioButton(engine.LittleEndian.String(), hd.order == engine.LittleEndian).OnChange(func() {
+					hd.order = engine.LittleEndian
+				}),
+			),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					hd.err = iv.loadHexDump(at)
+					if hd.err != nil {
+						return
+					}
+					hd.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					hd.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// load the binary file described by the hex dump dialog into the worksheet
+func (iv *ivycel) loadHexDump(at cells.Position) error {
+	hd := iv.hexDump
+
+	if hd.filename == "" {
+		return errors.New("no filename")
+	}
+
+	data, err := binaryfile.Read(hd.filename, int64(hd.offset), int64(hd.length))
+	if err != nil {
+		return err
+	}
+
+	rows, err := binaryfile.Dump(data, int64(hd.offset), int(hd.bytesPerRow),
+		hexDumpWidths[hd.width].width, hd.order)
+	if err != nil {
+		return err
+	}
+
+	// one column for the address and then one column for each value in a row
+	columns := int(hd.bytesPerRow)/hexDumpWidths[hd.width].width + 1
+
+	// a cell that holds part of a spilled result belongs to the cell that
+	// the result spilled from. changing it would change the other cell so the
+	// dump is not loaded over a spilled result, unless the result spilled from
+	// a cell that is also replaced by the dump. cells outside the worksheet
+	// don't exist yet and so can't hold a spilled result
+	area := cells.NewRange(at, cells.Position{Row: at.Row + len(rows) - 1, Column: at.Column + columns - 1})
+	wsRows, wsColumns := iv.worksheet.Size()
+	for ri, row := range rows {
+		for ci := range len(row.Values) + 1 {
+			if at.Row+ri >= wsRows || at.Column+ci >= wsColumns {
+				continue // for loop
+			}
+			cell := iv.worksheet.Cell(at.Row+ri, at.Column+ci)
+			if parent := cell.Parent(); parent != nil && !area.Contains(parent.Position()) {
+				return fmt.Errorf("%s holds a result spilled from %s",
+					cell.Position().Reference(), parent.Position().Reference())
+			}
+		}
+	}
+
+	// the worksheet is only made large enough for the dump once it is known
+	// that the dump can be loaded
+	iv.worksheet.Grow(at.Row+len(rows), at.Column+columns)
+
+	hex := engine.Base{Input: 16, Output: 16}
+
+	// cells in the dump are ordinary cells with a hexadecimal base. setting
+	// the base commits the cell
+	set := func(cell *cells.Cell, v uint64) {
+		cell.Entry = engine.FormatInteger(strconv.FormatUint(v, 16))
+		cell.SetBase(hex)
+	}
+
+	for ri, row := range rows {
+		set(iv.worksheet.Cell(at.Row+ri, at.Column), uint64(row.Address))
+		for ci, v := range row.Values {
+			set(iv.worksheet.Cell(at.Row+ri, at.Column+ci+1), v)
+		}
+	}
+
+	iv.worksheet.RecalculateAll()
+
+	return nil
+}
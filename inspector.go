@@ -0,0 +1,320 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// the value of the cell as an integer
+func (iv *ivycel) cellInteger(cell *cells.Cell) (*big.Int, error) {
+	var exact string
+	var err error
+
+	iv.ivy.WithErrorSupression(func() {
+		exact, err = iv.ivy.ExactValue(cell.Position().Reference())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := new(big.Int).SetString(exact, 10)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", cell.Position().Reference(), engine.NotAnInteger)
+	}
+
+	return v, nil
+}
+
+// the values from the engine that are shown by the inspector. the values are
+// only fetched again when the inspected cell changes or is committed
+type inspection struct {
+	cell       *cells.Cell
+	generation [2]int
+
+	result    string
+	resultErr error
+	shape     string
+	valueType string
+	exact     string
+	exactErr  error
+}
+
+// the inspection of the cell. a child cell is inspected along with its parent
+// so the inspection is also fetched again when the parent is committed
+func (iv *ivycel) inspect(cell *cells.Cell) *inspection {
+	root := cell
+	if cell.Parent() != nil {
+		root = cell.Parent()
+	}
+	generation := [2]int{cell.Generation(), root.Generation()}
+
+	in := &iv.inspection
+	if in.cell == cell && in.generation == generation {
+		return in
+	}
+
+	ref := root.Position().Reference()
+
+	*in = inspection{
+		cell:       cell,
+		generation: generation,
+		shape:      strings.TrimSpace(iv.ivy.Shape(ref)),
+		valueType:  iv.ivy.ValueType(ref),
+	}
+	in.result, in.resultErr = iv.fullResult(root)
+	iv.ivy.WithErrorSupression(func() {
+		in.exact, in.exactErr = iv.ivy.ExactValue(cell.Position().Reference())
+	})
+
+	return in
+}
+
+// the inspector shows detailed information about the selected cell
+func (iv *ivycel) inspector() {
+	if !iv.showInspector {
+		return
+	}
+
+	cell := iv.worksheet.User.(*worksheetUser).selected
+
+	giu.Window("Inspector").IsOpen(&iv.showInspector).Size(300, 400).Layout(
+		giu.Label(fmt.Sprintf("Cell %s", cell.Position().Reference())),
+		giu.Separator(),
+		iv.inspectCell(cell),
+		giu.Separator(),
+		iv.inspectBitfields(cell),
+		giu.Separator(),
+		iv.inspectFloat(cell),
+	)
+}
+
+// the full result of the cell as printed by the engine in the cell's output
+// base. the result shown in the worksheet is only the part of the result that
+// fits in the cell
+func (iv *ivycel) fullResult(cell *cells.Cell) (string, error) {
+	var r string
+	var err error
+
+	iv.ivy.WithErrorSupression(func() {
+		settingsErr := iv.ivy.WithSettings(cell.Settings(), func() {
+			baseErr := iv.ivy.WithNumberBase(cell.Base(), func() {
+				r, err = iv.ivy.Evaluate(references.WrapCellReference(cell.Position().Reference()))
+			})
+			if baseErr != nil {
+				err = baseErr
+			}
+		})
+		if settingsErr != nil {
+			err = settingsErr
+		}
+	})
+
+	return strings.TrimRight(r, "\n"), err
+}
+
+// the messages of the error and of each error that it wraps. the text of a
+// wrapped error is removed from the message of the error that wraps it so that
+// each message is only the part added at that level
+func errorChain(err error) []string {
+	var msgs []string
+	for err != nil {
+		msg := err.Error()
+		inner := errors.Unwrap(err)
+		if inner != nil {
+			msg = strings.TrimSuffix(strings.TrimSuffix(msg, inner.Error()), ": ")
+		}
+		if msg != "" {
+			msgs = append(msgs, msg)
+		}
+		err = inner
+	}
+	return msgs
+}
+
+// the entry, full result, shape, type, base and errors of the cell. if the
+// cell is a child of another cell then the information is for the parent
+func (iv *ivycel) inspectCell(cell *cells.Cell) giu.Widget {
+	var w []giu.Widget
+
+	root := cell
+	if cell.Parent() != nil {
+		root = cell.Parent()
+		w = append(w, giu.Label(fmt.Sprintf("Spilled from %s", root.Position().Reference())))
+	}
+
+	w = append(w, giu.Label(fmt.Sprintf("Entry: %s", root.Entry)).Wrapped(true))
+
+	if err := root.Error(); err != nil {
+		w = append(w, giu.Label("Error"))
+		for i, msg := range errorChain(err) {
+			w = append(w, giu.Label(fmt.Sprintf("%s%s", strings.Repeat("  ", i+1), msg)).Wrapped(true))
+		}
+		return giu.Column(w...)
+	}
+
+	if warn := root.Warning(); warn != nil {
+		w = append(w, giu.Label("Warning"))
+		for i, msg := range errorChain(warn) {
+			w = append(w, giu.Label(fmt.Sprintf("%s%s", strings.Repeat("  ", i+1), msg)).Wrapped(true))
+		}
+	}
+
+	in := iv.inspect(cell)
+	result := in.result
+	if in.resultErr != nil {
+		result = in.resultErr.Error()
+	}
+
+	shape := in.shape
+	if shape == "" {
+		shape = "scalar"
+	}
+
+	// the source of each part of the base is either the cell or the worksheet
+	base := root.Base()
+	source := func(override int) string {
+		if override == 0 {
+			return "default"
+		}
+		return "cell"
+	}
+
+	settings := root.Settings()
+	override := root.SettingsOverride()
+	format := settings.Format
+	if format == "" {
+		format = "none"
+	}
+
+	w = append(w,
+		giu.Label("Result"),
+		giu.InputTextMultiline(&result).
+			Flags(giu.InputTextFlagsReadOnly).
+			Size(-1, 100),
+		giu.Table().
+			Flags(giu.TableFlagsBorders|giu.TableFlagsRowBg).
+			Columns(
+				giu.TableColumn("Property"),
+				giu.TableColumn("Value"),
+			).
+			Rows(
+				giu.TableRow(giu.Label("Shape"), giu.Label(shape)),
+				giu.TableRow(giu.Label("Type"), giu.Label(in.valueType)),
+				giu.TableRow(giu.Label("Input base"),
+					giu.Label(fmt.Sprintf("%d (%s)", base.Input, source(root.BaseOverride().Input)))),
+				giu.TableRow(giu.Label("Output base"),
+					giu.Label(fmt.Sprintf("%d (%s)", base.Output, source(root.BaseOverride().Output)))),
+				giu.TableRow(giu.Label("Precision"),
+					giu.Label(fmt.Sprintf("%d (%s)", settings.Precision, source(override.Precision)))),
+				giu.TableRow(giu.Label("Format"),
+					giu.Label(fmt.Sprintf("%s (%s)", format, source(len(override.Format))))),
+				giu.TableRow(giu.Label("Maximum digits"),
+					giu.Label(fmt.Sprintf("%d (%s)", settings.MaxDigits, source(override.MaxDigits)))),
+				giu.TableRow(giu.Label("Origin"),
+					giu.Label(fmt.Sprintf("%d (%s)", settings.Origin.Index(), source(int(override.Origin))))),
+				giu.TableRow(giu.Label("Display"), giu.Label(root.Display().String())),
+				giu.TableRow(giu.Label("Volatile"), giu.Label(fmt.Sprintf("%v", iv.worksheet.Volatile(root)))),
+				giu.TableRow(giu.Label("Validation"), giu.Label(validationSummary(iv.worksheet.Validation(root)))),
+			),
+	)
+
+	return giu.Column(w...)
+}
+
+// the sign, exponent and mantissa of the cell's value when the cell is using
+// one of the IEEE-754 displays
+func (iv *ivycel) inspectFloat(cell *cells.Cell) giu.Widget {
+	display := cell.Display()
+	if !display.IsFloat() {
+		return giu.Label("No IEEE-754 display")
+	}
+
+	in := iv.inspect(cell)
+	if in.exactErr != nil {
+		return giu.Label(in.exactErr.Error())
+	}
+
+	bits, err := display.Bits(in.exact)
+	if err != nil {
+		return giu.Label(err.Error())
+	}
+
+	p := display.Parts(bits)
+	digits := display.Width() / 4
+
+	return giu.Column(
+		giu.Label(display.String()),
+		giu.Table().
+			Flags(giu.TableFlagsBorders|giu.TableFlagsRowBg).
+			Columns(
+				giu.TableColumn("Part"),
+				giu.TableColumn("Value"),
+			).
+			Rows(
+				giu.TableRow(giu.Label("Bits"), giu.Label(fmt.Sprintf("%0*x", digits, bits))),
+				giu.TableRow(giu.Label("Sign"), giu.Label(fmt.Sprintf("%d", p.Sign))),
+				giu.TableRow(giu.Label("Exponent"), giu.Label(fmt.Sprintf("%x (%d)", p.Exponent, p.Unbiased))),
+				giu.TableRow(giu.Label("Mantissa"), giu.Label(fmt.Sprintf("%x", p.Mantissa))),
+			),
+	)
+}
+
+// the value of the cell split into the fields of the cell's bitfield layout.
+// each field is shown in its own number base
+func (iv *ivycel) inspectBitfields(cell *cells.Cell) giu.Widget {
+	if cell.Layout() == "" {
+		return giu.Label("No bitfield layout")
+	}
+
+	layout, ok := iv.worksheet.Layout(cell.Layout())
+	if !ok {
+		return giu.Label(fmt.Sprintf("Missing bitfield layout %s", cell.Layout()))
+	}
+
+	in := iv.inspect(cell)
+	if in.exactErr != nil {
+		return giu.Label(in.exactErr.Error())
+	}
+	v, ok := new(big.Int).SetString(in.exact, 10)
+	if !ok {
+		return giu.Label(fmt.Sprintf("%s: %s", cell.Position().Reference(), engine.NotAnInteger))
+	}
+
+	var rows []*giu.TableRowWidget
+	for _, f := range layout.Fields {
+		base := f.Base
+		if base == 0 {
+			base = cell.Base().Output
+		}
+
+		bits := fmt.Sprintf("%d", f.Low)
+		if f.High != f.Low {
+			bits = fmt.Sprintf("%d:%d", f.High, f.Low)
+		}
+
+		rows = append(rows, giu.TableRow(
+			giu.Label(f.Name),
+			giu.Label(bits),
+			giu.Label(f.Extract(v).Text(base)),
+		))
+	}
+
+	return giu.Column(
+		giu.Label(fmt.Sprintf("Layout %s", cell.Layout())),
+		giu.Table().
+			Flags(giu.TableFlagsBorders|giu.TableFlagsRowBg).
+			Columns(
+				giu.TableColumn("Field"),
+				giu.TableColumn("Bits"),
+				giu.TableColumn("Value"),
+			).
+			Rows(rows...),
+	)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"strings"
+	"unsafe"
+
+	imgui "github.com/AllenDang/cimgui-go"
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+// characters typed by the user since the previous update
+func typedCharacters() string {
+	q := imgui.CurrentIO().InputQueueCharacters()
+	if q.Size == 0 {
+		return ""
+	}
+
+	var s strings.Builder
+	for _, c := range unsafe.Slice(q.Data, q.Size) {
+		s.WriteRune(rune(c))
+	}
+	return s.String()
+}
+
+// select cell and make sure that it is visible in the worksheet. any other
+// cells in the selection are deselected
+func (iv *ivycel) selectCell(cell *cells.Cell) {
+	wu := iv.worksheet.User.(*worksheetUser)
+	wu.selected = cell
+	wu.selection.Set(cell.Position())
+	wu.scrollVertical = true
+	wu.scrollHorizontal = true
+}
+
+// start editing the cell with the supplied entry. the original entry is kept
+// so that it can be restored if editing is cancelled. editing starts in point
+// mode if point is true
+func (iv *ivycel) editCell(cell *cells.Cell, entry string, point bool) {
+	if cell.ReadOnly() {
+		return
+	}
+
+	wu := iv.worksheet.User.(*worksheetUser)
+	wu.editing = cell
+	wu.editOriginal = cell.Entry
+	wu.focusCell = true
+	wu.pointMode = point
+
+	cell.Entry = entry
+	cell.User.(*cellUser).editCursorPosition = len(entry)
+}
+
+// clear the entry of the cells
+func (iv *ivycel) clearCells(cs ...*cells.Cell) {
+	for _, cell := range cs {
+		if cell.ReadOnly() {
+			continue
+		}
+		cell.Entry = ""
+		cell.Commit(true)
+	}
+	iv.worksheet.RecalculateAll()
+}
+
+// move the selection by the specified amount. the new selection is limited to
+// the size of the worksheet
+func (iv *ivycel) moveSelection(adj cells.Adjustment) {
+	p := iv.clampPosition(iv.worksheet.User.(*worksheetUser).selected.Position().Adjust(adj))
+	iv.selectCell(iv.worksheet.Cell(p.Row, p.Column))
+}
+
+// keyboard handling for the worksheet when no cell is being edited. nothing
+// happens if another widget is using the keyboard
+func (iv *ivycel) keyboardNavigation() {
+	wu := iv.worksheet.User.(*worksheetUser)
+
+	if wu.editing != nil {
+		return
+	}
+
+	if imgui.CurrentIO().WantTextInput() || imgui.IsPopupOpenStrV("", imgui.PopupFlagsAnyPopup) {
+		return
+	}
+
+	shift := imgui.CurrentIO().KeyShift()
+	ctrl := imgui.CurrentIO().KeyCtrl()
+	rows, columns := iv.worksheet.Size()
+	pos := wu.selected.Position()
+
+	// the arrow keys extend the selection if shift is held
+	move := iv.moveSelection
+	if shift {
+		move = iv.extendSelection
+	}
+
+	switch {
+	case giu.IsKeyPressed(giu.KeyUp):
+		move(cells.Adjustment{Row: -1})
+	case giu.IsKeyPressed(giu.KeyDown):
+		move(cells.Adjustment{Row: 1})
+	case giu.IsKeyPressed(giu.KeyLeft):
+		move(cells.Adjustment{Column: -1})
+	case giu.IsKeyPressed(giu.KeyRight):
+		move(cells.Adjustment{Column: 1})
+	case giu.IsKeyPressed(giu.KeyTab):
+		if shift {
+			iv.moveSelection(cells.Adjustment{Column: -1})
+		} else {
+			iv.moveSelection(cells.Adjustment{Column: 1})
+		}
+	case giu.IsKeyPressed(giu.KeyEnter) || giu.IsKeyPressed(giu.KeyNumPadEnter):
+		if shift {
+			iv.moveSelection(cells.Adjustment{Row: -1})
+		} else {
+			iv.moveSelection(cells.Adjustment{Row: 1})
+		}
+	case giu.IsKeyPressed(giu.KeyPageUp):
+		iv.moveSelection(cells.Adjustment{Row: -wu.visibleRows})
+	case giu.IsKeyPressed(giu.KeyPageDown):
+		iv.moveSelection(cells.Adjustment{Row: wu.visibleRows})
+	case giu.IsKeyPressed(giu.KeyHome):
+		if ctrl {
+			iv.moveSelection(cells.Adjustment{Row: -pos.Row, Column: -pos.Column})
+		} else {
+			iv.moveSelection(cells.Adjustment{Column: -pos.Column})
+		}
+	case giu.IsKeyPressed(giu.KeyEnd):
+		if ctrl {
+			iv.moveSelection(cells.Adjustment{Row: rows - 1 - pos.Row, Column: columns - 1 - pos.Column})
+		} else {
+			iv.moveSelection(cells.Adjustment{Column: columns - 1 - pos.Column})
+		}
+	case giu.IsKeyPressed(giu.KeyF2):
+		iv.editCell(wu.selected, wu.selected.Entry, false)
+	case giu.IsKeyPressed(giu.KeyDelete):
+		iv.clearCells(iv.selectedCells()...)
+	case ctrl && giu.IsKeyPressed(giu.KeyC):
+		iv.copySelection()
+	case ctrl && giu.IsKeyPressed(giu.KeyZ):
+		iv.undo()
+	case giu.IsKeyPressed(giu.KeyF9):
+		iv.worksheet.Calculate()
+	default:
+		// typing while a cell is selected replaces the entry of the cell.
+		// editing starts in point mode so that references can be entered with
+		// the arrow keys
+		if s := typedCharacters(); s != "" && !ctrl {
+			iv.editCell(wu.selected, s, true)
+		}
+	}
+}
+
+// scroll the worksheet vertically so that the cursor of the selection is
+// visible. this must be called from inside the worksheet table. rows may not
+// have been drawn if they're not visible so the position of the row is
+// calculated
+func (iv *ivycel) scrollVertically(rowHeight float32) {
+	wu := iv.worksheet.User.(*worksheetUser)
+
+	// the frozen header row is always visible and reduces the height available
+	// for the worksheet rows
+	pitch := rowHeight + imgui.CurrentStyle().CellPadding().Y*2
+	view := imgui.WindowHeight() - pitch
+	wu.visibleRows = max(1, int(view/pitch))
+
+	if !wu.scrollVertical {
+		return
+	}
+	wu.scrollVertical = false
+
+	top := float32(wu.selection.Cursor().Row) * pitch
+	scroll := imgui.ScrollY()
+	if top < scroll {
+		imgui.SetScrollYFloat(top)
+	} else if top+pitch > scroll+view {
+		imgui.SetScrollYFloat(top + pitch - view)
+	}
+}
+
+// scroll the worksheet horizontally so that the cell is visible. this must be
+// called before the cell is drawn and only if the cell is at the cursor of the
+// selection
+func (iv *ivycel) scrollHorizontally(rowHeaderWidth float32) {
+	wu := iv.worksheet.User.(*worksheetUser)
+	if !wu.scrollHorizontal {
+		return
+	}
+	wu.scrollHorizontal = false
+
+	// the frozen row header is always visible and reduces the width available
+	// for the worksheet columns
+	left := imgui.WindowPos().X + rowHeaderWidth
+	right := imgui.WindowPos().X + imgui.WindowWidth()
+	x := imgui.CursorScreenPos().X
+	if x < left || x+imgui.ContentRegionAvail().X > right {
+		imgui.SetScrollHereXV(0.5)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/bitfields"
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+// state of the bitfield layout dialog
+type layoutEditor struct {
+	// the cell that will use the layout once the dialog is confirmed. can be
+	// nil if the layout isn't being created for a specific cell
+	cell *cells.Cell
+
+	// the original name of the layout being edited. an empty string means that
+	// a new layout is being created
+	original string
+
+	name string
+	spec string
+	err  error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the bitfield layout menu for a cell. the menu allows the user to choose
+// from any layout in the worksheet or to create a new layout
+func (iv *ivycel) layoutMenu(cell *cells.Cell) giu.Widget {
+	return giu.Menu("Bitfield Layout").Layout(
+		giu.Custom(func() {
+			giu.MenuItem("None").Selected(cell.Layout() == "").OnClick(func() {
+				cell.SetLayout("")
+				iv.worksheet.RecalculateAll()
+			}).Build()
+
+			for _, name := range iv.worksheet.Layouts() {
+				giu.MenuItem(name).Selected(cell.Layout() == name).OnClick(func() {
+					cell.SetLayout(name)
+					iv.worksheet.RecalculateAll()
+				}).Build()
+			}
+		}),
+		giu.Spacing(),
+		giu.Separator(),
+		giu.Spacing(),
+		giu.MenuItem("New Layout...").OnClick(func() {
+			iv.layoutEditor = layoutEditor{
+				cell:   cell,
+				open:   true,
+				active: true,
+			}
+		}),
+		giu.MenuItem("Edit Layout...").
+			Enabled(cell.Layout() != "").
+			OnClick(func() {
+				l, _ := iv.worksheet.Layout(cell.Layout())
+				iv.layoutEditor = layoutEditor{
+					cell:     cell,
+					original: cell.Layout(),
+					name:     cell.Layout(),
+					spec:     l.String(),
+					open:     true,
+					active:   true,
+				}
+			}),
+		giu.MenuItem("Delete Layout").
+			Enabled(cell.Layout() != "").
+			OnClick(func() {
+				iv.worksheet.DeleteLayout(cell.Layout())
+			}),
+	)
+}
+
+// the layout dialog allows the user to name and describe a bitfield layout
+func (iv *ivycel) layoutEditorModal() giu.Widget {
+	const popupName = "Bitfield Layout"
+
+	return giu.Custom(func() {
+		if !iv.layoutEditor.active {
+			return
+		}
+
+		if iv.layoutEditor.open {
+			iv.layoutEditor.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var errLabel giu.Widget
+		if iv.layoutEditor.err != nil {
+			errLabel = giu.Label(iv.layoutEditor.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label("Name"),
+			giu.InputText(&iv.layoutEditor.name).Size(300),
+			giu.Label("Fields (eg. EN[0] MODE[3:1] DIV[15:8]:16)"),
+			giu.InputText(&iv.layoutEditor.spec).Size(300),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					name := strings.TrimSpace(iv.layoutEditor.name)
+					if name == "" {
+						iv.layoutEditor.err = fmt.Errorf("%w: layout must have a name", bitfields.IllegalLayout)
+						return
+					}
+
+					l, err := bitfields.Parse(iv.layoutEditor.spec)
+					if err != nil {
+						iv.layoutEditor.err = err
+						return
+					}
+
+					// a renamed layout replaces the original layout for
+					// every cell that used it
+					if iv.layoutEditor.original != "" && iv.layoutEditor.original != name {
+						if err := iv.worksheet.RenameLayout(iv.layoutEditor.original, name); err != nil {
+							iv.layoutEditor.err = err
+							return
+						}
+					}
+
+					if iv.layoutEditor.cell != nil {
+						iv.layoutEditor.cell.SetLayout(name)
+					}
+					iv.worksheet.SetLayout(name, l)
+
+					iv.layoutEditor.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					iv.layoutEditor.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"strconv"
 	"strings"
 
 	imgui "github.com/AllenDang/cimgui-go"
@@ -13,24 +15,40 @@ import (
 	"github.com/jetsetilly/ivycel/engine/ivy"
 	"github.com/jetsetilly/ivycel/fonts"
 	"github.com/jetsetilly/ivycel/references"
+	"github.com/jetsetilly/ivycel/validation"
 	"github.com/jetsetilly/ivycel/worksheet"
 )
 
 type ivycel struct {
-	ivy ivy.Ivy
+	ivy *ivy.Ivy
 
 	worksheet worksheet.Worksheet
 
 	cellNormalStyle   *giu.StyleSetter
 	cellReadOnlyStyle *giu.StyleSetter
 	cellEditStyle     *giu.StyleSetter
+	cellSelectedStyle *giu.StyleSetter
 	contextMenuStyle  *giu.StyleSetter
 	headerStyle       *giu.StyleSetter
 
+	// styles for cells that are part of a trace
+	cellPrecedentStyle *giu.StyleSetter
+	cellDependentStyle *giu.StyleSetter
+
+	// style for cells that depend on a cell in error
+	cellDependsOnErrorStyle *giu.StyleSetter
+
+	// style for cells that need to be recalculated in manual calculation mode
+	cellStaleStyle *giu.StyleSetter
+
 	// badge styling should push the badges style first and then the specific badge type
 	badges          *giu.StyleSetter
 	outputBaseBadge *giu.StyleSetter
 	inputBaseBadge  *giu.StyleSetter
+	volatileBadge   *giu.StyleSetter
+
+	// badge for cells with a value that failed validation
+	validationBadge *giu.StyleSetter
 
 	statusBarHeight int
 
@@ -43,15 +61,151 @@ type ivycel struct {
 
 	// the current context menu level being shown for cells
 	cellContextMenuLevel int
+
+	// the custom base dialog is opened from a menu but must be drawn outside
+	// of the menu
+	customBase customBase
+
+	// the settings dialog is opened from a menu but must be drawn outside of
+	// the menu
+	settings settingsDialog
+
+	// the random seed dialog is opened from the worksheet menu but must be
+	// drawn outside of the menu
+	seedDialog seedDialog
+
+	// the iterative calculation dialog is opened from the worksheet menu but
+	// must be drawn outside of the menu
+	iterationDialog iterationDialog
+
+	// the goal seek dialog is opened from the cell context menu but must be
+	// drawn outside of the context menu
+	goalSeek goalSeek
+
+	// the data table window shows the results of a what-if analysis
+	dataTable dataTable
+
+	// the scenarios window switches between sets of input values
+	scenarios scenarios
+
+	// the conditional formatting window lists the rules of the worksheet
+	formatting formatting
+
+	// the validation dialog is opened from the cell context menu but must be
+	// drawn outside of the context menu
+	validation validationDialog
+
+	// the reason the most recent edit was rejected by validation. cleared
+	// on the next edit
+	rejected error
+
+	// changes that can be undone, with the most recent change last
+	undoSteps []undoStep
+
+	// the reason the most recent undo failed. cleared on the next undo or
+	// edit
+	undoFailed error
+
+	// the bitfield layout dialog is opened from the cell context menu but
+	// must be drawn outside of the context menu
+	layoutEditor layoutEditor
+
+	// the reassemble bytes dialog is opened from the cell context menu but
+	// must be drawn outside of the context menu
+	reassembleBytes reassembleBytes
+
+	// the hex dump dialog is opened from the file menu but must be drawn
+	// outside of the menu
+	hexDump hexDump
+
+	// the export dialog is opened from the file menu but must be drawn
+	// outside of the menu
+	export export
+
+	// whether the inspector window is open
+	showInspector bool
+
+	// the values shown by the inspector for the selected cell
+	inspection inspection
+
+	// completion of operators, names and references in the cell being
+	// edited or the formula bar
+	autocomplete autocomplete
+
+	// the precedents or dependents of a cell
+	trace trace
+
+	// the dependency graph export dialog is opened from the file menu but
+	// must be drawn outside of the menu
+	dotExport dotExport
+
+	// the open and save dialogs are opened from the file menu but must be
+	// drawn outside of the menu
+	file worksheetFile
+}
+
+// number bases that are offered by name in the base menus. any other base
+// supported by the engine can be chosen with the custom base dialog
+var namedBases = []struct {
+	label string
+	base  int
+}{
+	{label: "Binary", base: 2},
+	{label: "Octal", base: 8},
+	{label: "Decimal", base: 10},
+	{label: "Hexadecimal", base: 16},
+}
+
+func isNamedBase(base int) bool {
+	for _, b := range namedBases {
+		if b.base == base {
+			return true
+		}
+	}
+	return false
+}
+
+// state of the custom base dialog
+type customBase struct {
+	title string
+	base  int32
+
+	// apply is called with the chosen base when the dialog is confirmed. the
+	// dialog stays open and shows the error if the base can't be used
+	apply func(base int) error
+	err   error
+
+	// the dialog should be opened on the next update
+	open bool
 }
 
 type worksheetUser struct {
 	selected *cells.Cell
 	editing  *cells.Cell
 
+	// the selection always includes the selected cell when it is first
+	// created but extending the selection doesn't change the selected cell
+	selection cells.Selection
+
+	// the entry of the cell being edited before editing started. it is
+	// restored if editing is cancelled or if the edit is rejected by
+	// validation
+	editOriginal string
+
+	// in point mode the arrow keys insert and move references in the cell
+	// being edited rather than move the edit cursor
+	pointMode bool
+
 	// focus either the cell being edited or the formula bar on the next update
 	focusCell    bool
 	focusFormula bool
+
+	// scroll the worksheet so that the selected cell is visible
+	scrollVertical   bool
+	scrollHorizontal bool
+
+	// the number of rows visible in the worksheet. used for paging
+	visibleRows int
 }
 
 type cellUser struct {
@@ -128,11 +282,18 @@ func (iv *ivycel) cellContextMenu(cell *cells.Cell) giu.Widget {
 					giu.MenuItem(fmt.Sprintf(" Reference to root of %s", cell.Position().Reference())).
 						OnClick(func() {
 							iv.insertIntoCellEdit(references.WrapCellReference(
-								fmt.Sprintf("%s%s", cell.Position().Reference(), cell.RootIndex()),
+								fmt.Sprintf("%s%s", cell.Position().Reference(), cell.RootIndex(editCell.Settings().Origin)),
 							))
 						}).Build()
 				}
 
+				if sel := iv.worksheet.User.(*worksheetUser).selection; !sel.IsSingle() {
+					giu.MenuItem(fmt.Sprintf(" Reference to selection (%s)", sel.Active().Reference())).
+						OnClick(func() {
+							iv.insertIntoCellEdit(references.WrapCellReference(sel.Active().Reference()))
+						}).Build()
+				}
+
 				if strings.TrimSpace(cell.Result()) != "" {
 					giu.MenuItem(fmt.Sprintf(" Literal value of %v", cell.Result())).
 						OnClick(func() {
@@ -144,19 +305,44 @@ func (iv *ivycel) cellContextMenu(cell *cells.Cell) giu.Widget {
 		return menu
 	}
 
+	// clear and the base menus apply to every cell in the selection if the
+	// cell is part of the selection
+	targets := iv.contextCells(cell)
+	title := fmt.Sprintf("Cell %s", cell.Position().Reference())
+	if len(targets) > 1 {
+		title = fmt.Sprintf("Selection %s", iv.worksheet.User.(*worksheetUser).selection.Reference())
+	}
+
+	// the base menus work with the base override of each cell. the effective
+	// base of the clicked cell is used to decide which menu item is selected
 	cellBase := cell.Base()
 
-	inputBase := func(label string, newBase int) giu.Widget {
-		return giu.MenuItem(label).Selected(cellBase.Input == newBase).OnClick(func() {
-			cell.SetBase(engine.Base{Input: newBase, Output: cellBase.Output})
-		})
+	var overrideInput, overrideOutput, hasEntry bool
+	for _, c := range targets {
+		overrideInput = overrideInput || c.BaseOverride().Input != 0
+		overrideOutput = overrideOutput || c.BaseOverride().Output != 0
+		hasEntry = hasEntry || c.Entry != ""
 	}
 
-	outputBase := func(label string, newBase int) giu.Widget {
-		return giu.MenuItem(label).Selected(cellBase.Output == newBase).OnClick(func() {
-			cell.SetBase(engine.Base{Input: cellBase.Input, Output: newBase})
+	inputBase := iv.baseMenu(cellBase.Input, fmt.Sprintf("Input base for %s", title),
+		func(newBase int) error {
+			for _, c := range targets {
+				if err := c.SetBase(engine.Base{Input: newBase, Output: c.BaseOverride().Output}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	outputBase := iv.baseMenu(cellBase.Output, fmt.Sprintf("Output base for %s", title),
+		func(newBase int) error {
+			for _, c := range targets {
+				if err := c.SetBase(engine.Base{Input: c.BaseOverride().Input, Output: newBase}); err != nil {
+					return err
+				}
+			}
+			return nil
 		})
-	}
 
 	return giu.ContextMenu().Layout(
 		giu.Custom(func() {
@@ -164,71 +350,298 @@ func (iv *ivycel) cellContextMenu(cell *cells.Cell) giu.Widget {
 			defer iv.contextMenuStyle.Pop()
 
 			giu.Column(
-				giu.Label(fmt.Sprintf("Cell %s", cell.Position().Reference())),
+				giu.Label(title),
 				giu.Spacing(),
 				giu.Separator(),
 				giu.Spacing(),
 				giu.MenuItem("Clear").
-					Enabled(cell.Entry != "").
+					Enabled(hasEntry).
 					OnClick(func() {
-						cell.Entry = ""
-						cell.Commit(true)
-						iv.worksheet.RecalculateAll()
+						iv.clearCells(targets...)
+					}),
+				giu.MenuItem("Copy").
+					OnClick(func() {
+						if len(targets) == 1 {
+							iv.selectCell(cell)
+						}
+						iv.copySelection()
 					}),
 				giu.Menu("Input Base").Layout(
-					inputBase("Binary", 2),
-					inputBase("Octal", 8),
-					inputBase("Decimal", 10),
-					inputBase("Hexadecimal", 16),
+					inputBase,
 					giu.Spacing(),
 					giu.Separator(),
 					giu.Spacing(),
 					giu.MenuItem("Reset").
-						Enabled(cellBase != iv.ivy.Base()).
+						Enabled(overrideInput).
 						OnClick(func() {
-							base := cellBase
-							base.Input = iv.ivy.Base().Input
-							cell.SetBase(base)
+							for _, c := range targets {
+								c.SetBase(engine.Base{Output: c.BaseOverride().Output})
+							}
 						}),
 				),
 				giu.Menu("Output Base").Layout(
-					outputBase("Binary", 2),
-					outputBase("Octal", 8),
-					outputBase("Decimal", 10),
-					outputBase("Hexadecimal", 16),
+					outputBase,
 					giu.Spacing(),
 					giu.Separator(),
 					giu.Spacing(),
 					giu.MenuItem("Reset").
-						Enabled(cellBase != iv.ivy.Base()).
+						Enabled(overrideOutput).
 						OnClick(func() {
-							base := cellBase
-							base.Output = iv.ivy.Base().Output
-							cell.SetBase(base)
+							for _, c := range targets {
+								c.SetBase(engine.Base{Input: c.BaseOverride().Input})
+							}
 						}),
 				),
+				giu.Menu("Display").Layout(
+					giu.Custom(func() {
+						for _, d := range []engine.Display{
+							engine.DisplayValue,
+							engine.DisplayFloat32,
+							engine.DisplayFloat64,
+							engine.DisplayFloat32Bits,
+							engine.DisplayFloat64Bits,
+						} {
+							giu.MenuItem(d.String()).Selected(cell.Display() == d).OnClick(func() {
+								cell.SetDisplay(d)
+								iv.worksheet.RecalculateAll()
+							}).Build()
+						}
+					}),
+				),
+				iv.bytesMenu(cell),
+				iv.layoutMenu(cell),
+				giu.MenuItem("Settings...").
+					OnClick(func() {
+						iv.openSettings(fmt.Sprintf("Settings for %s", title), cell.SettingsOverride(),
+							func(s engine.Settings) error {
+								for _, c := range targets {
+									if err := c.SetSettings(s); err != nil {
+										return err
+									}
+								}
+								iv.worksheet.RecalculateAll()
+								return nil
+							})
+					}),
+				giu.MenuItem("Validation...").
+					OnClick(func() {
+						iv.openValidation(fmt.Sprintf("Validation for %s", title), targets)
+					}),
+				giu.Spacing(),
+				giu.Separator(),
+				giu.Spacing(),
+				giu.MenuItem("Goal Seek...").OnClick(func() {
+					iv.openGoalSeek(cell)
+				}),
+				giu.MenuItem("Data Table...").OnClick(func() {
+					iv.openDataTable(cell)
+				}),
+				giu.MenuItem("Trace Precedents").OnClick(func() {
+					iv.traceCell(cell, tracePrecedents)
+				}),
+				giu.MenuItem("Trace Dependents").OnClick(func() {
+					iv.traceCell(cell, traceDependents)
+				}),
+				giu.Custom(func() {
+					var dep cells.DependencyError
+					if errors.As(cell.Error(), &dep) {
+						giu.MenuItem(fmt.Sprintf("Go to Error in %s", dep.Origin.Reference())).OnClick(func() {
+							iv.selectCell(iv.worksheet.Cell(dep.Origin.Row, dep.Origin.Column))
+						}).Build()
+					}
+				}),
 			).Build()
 		}),
 	)
 }
 
+// the worksheet menu in the menu bar contains settings that affect the entire
+// worksheet
+func (iv *ivycel) worksheetMenu() giu.Widget {
+	defaultBase := iv.worksheet.DefaultBase()
+
+	// errors from SetDefaultBase() are reported through the status bar so
+	// there is no need to handle the error here
+
+	return giu.Menu(string(fonts.WorksheetMenu)).Layout(
+		giu.Label("Worksheet"),
+		giu.Separator(),
+		giu.Menu("Default Input Base").Layout(
+			iv.baseMenu(defaultBase.Input, "Default input base", func(newBase int) error {
+				return iv.worksheet.SetDefaultBase(engine.Base{Input: newBase, Output: defaultBase.Output})
+			}),
+		),
+		giu.Menu("Default Output Base").Layout(
+			iv.baseMenu(defaultBase.Output, "Default output base", func(newBase int) error {
+				return iv.worksheet.SetDefaultBase(engine.Base{Input: defaultBase.Input, Output: newBase})
+			}),
+		),
+		giu.MenuItem("Settings...").OnClick(func() {
+			iv.openSettings("Settings for the worksheet", iv.worksheet.DefaultSettings(),
+				iv.worksheet.SetDefaultSettings)
+		}),
+		giu.MenuItem("Random Seed...").OnClick(func() {
+			iv.seedDialog = seedDialog{
+				seed:   strconv.FormatInt(iv.worksheet.Seed(), 10),
+				open:   true,
+				active: true,
+			}
+		}),
+		giu.MenuItem("Recalculate Volatile").OnClick(func() {
+			iv.worksheet.RecalculateVolatile()
+		}),
+		giu.Spacing(),
+		giu.Separator(),
+		giu.Spacing(),
+		giu.Menu("Calculation").Layout(
+			giu.Custom(func() {
+				for _, c := range []worksheet.Calculation{
+					worksheet.CalculationAutomatic,
+					worksheet.CalculationManual,
+				} {
+					giu.MenuItem(c.String()).Selected(iv.worksheet.Calculation() == c).OnClick(func() {
+						iv.worksheet.SetCalculation(c)
+					}).Build()
+				}
+			}),
+		),
+		giu.MenuItem("Iterative Calculation...").OnClick(func() {
+			it := iv.worksheet.Iteration()
+			iv.iterationDialog = iterationDialog{
+				enabled:       it.Enabled,
+				maxIterations: int32(it.MaxIterations),
+				tolerance:     float32(it.Tolerance),
+				open:          true,
+				active:        true,
+			}
+		}),
+		giu.MenuItem("Calculate Now").
+			Shortcut("F9").
+			Enabled(iv.worksheet.DirtyCount() > 0).
+			OnClick(func() {
+				iv.worksheet.Calculate()
+			}),
+		giu.Spacing(),
+		giu.Separator(),
+		giu.Spacing(),
+		giu.MenuItem(fmt.Sprintf("Undo %s", iv.undoLabel())).
+			Shortcut("Ctrl+Z").
+			Enabled(iv.undoLabel() != "").
+			OnClick(func() {
+				iv.undo()
+			}),
+		giu.MenuItem("Conditional Formatting").Selected(iv.formatting.active).OnClick(func() {
+			if iv.formatting.active {
+				iv.formatting.active = false
+			} else {
+				iv.openFormatting()
+			}
+		}),
+		giu.MenuItem("Scenarios").Selected(iv.scenarios.active).OnClick(func() {
+			iv.scenarios.active = !iv.scenarios.active
+		}),
+		giu.MenuItem("Inspector").Selected(iv.showInspector).OnClick(func() {
+			iv.showInspector = !iv.showInspector
+		}),
+	)
+}
+
+// the menu items for choosing a number base. the named bases are offered
+// first followed by an option to open the custom base dialog. the apply function
+// is called with the chosen base. the named bases are always valid so an error
+// can only come from the custom base dialog, which shows the error
+func (iv *ivycel) baseMenu(current int, title string, apply func(base int) error) giu.Widget {
+	return giu.Custom(func() {
+		for _, b := range namedBases {
+			giu.MenuItem(b.label).Selected(current == b.base).OnClick(func() {
+				_ = apply(b.base)
+			}).Build()
+		}
+
+		label := "Custom..."
+		if !isNamedBase(current) {
+			label = fmt.Sprintf("Custom (%d)...", current)
+		}
+		giu.MenuItem(label).Selected(!isNamedBase(current)).OnClick(func() {
+			iv.customBase = customBase{
+				title: title,
+				base:  int32(current),
+				apply: apply,
+				open:  true,
+			}
+		}).Build()
+	})
+}
+
+// the custom base dialog allows the user to choose a number base that isn't
+// offered by name in the base menus
+func (iv *ivycel) customBaseModal() giu.Widget {
+	const popupName = "Custom Base"
+
+	return giu.Custom(func() {
+		if iv.customBase.apply == nil {
+			return
+		}
+
+		if iv.customBase.open {
+			iv.customBase.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var errLabel giu.Widget
+		if iv.customBase.err != nil {
+			errLabel = giu.Label(iv.customBase.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label(iv.customBase.title),
+			giu.InputInt(&iv.customBase.base).Size(100),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					iv.customBase.err = iv.customBase.apply(int(iv.customBase.base))
+					if iv.customBase.err != nil {
+						return
+					}
+					iv.customBase.apply = nil
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					iv.customBase.apply = nil
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
 func (iv *ivycel) layout() {
 	iv.preloadFonts()
+	iv.keyboardNavigation()
+	iv.updateTrace()
 
 	var selected *giu.LabelWidget
 	selected = giu.Label(iv.worksheet.User.(*worksheetUser).selected.Position().Reference())
 
 	var formula *giu.InputTextWidget
 	formula = giu.InputText(&iv.worksheet.User.(*worksheetUser).selected.Entry).
-		Flags(giu.InputTextFlagsEnterReturnsTrue).
+		Flags(giu.InputTextFlagsEnterReturnsTrue | autocompleteFlags).
+		Callback(func(data imgui.InputTextCallbackData) int {
+			iv.autocompleteCallback(&data)
+			return 0
+		}).
 		OnChange(func() {
-			iv.worksheet.User.(*worksheetUser).selected.Commit(true)
-			iv.worksheet.RecalculateAll()
+			iv.commitEdit(iv.worksheet.User.(*worksheetUser).selected)
 			iv.worksheet.User.(*worksheetUser).editing = nil
 			iv.worksheet.User.(*worksheetUser).focusFormula = true
 		}).
 		Size(-1)
 
+	// references in the cell being edited are outlined in the worksheet
+	editRefs := iv.editReferences()
+
 	// the main body of the spreadsheet is a table
 	var worksheet *giu.TableWidget
 	{
@@ -271,7 +684,13 @@ func (iv *ivycel) layout() {
 
 		{ // add column headers manually
 			var rowCols []giu.Widget
-			rowCols = append(rowCols, giu.Label(""))
+
+			// the first header cell is always drawn and so is a good place
+			// to scroll the table if required
+			rowCols = append(rowCols, giu.Custom(func() {
+				iv.scrollVertically(rowHeight)
+				giu.Label("").Build()
+			}))
 			for coli := range colCt {
 				rowCols = append(rowCols,
 					giu.Custom(func() {
@@ -336,7 +755,7 @@ func (iv *ivycel) layout() {
 						iv.badges.Push()
 						defer iv.badges.Pop()
 
-						if bs.Output != iv.ivy.Base().Output {
+						if bs.Output != iv.worksheet.DefaultBase().Output {
 							iv.outputBaseBadge.Push()
 							defer iv.outputBaseBadge.Pop()
 							txt := fmt.Sprintf("%d", bs.Output)
@@ -345,7 +764,7 @@ func (iv *ivycel) layout() {
 							giu.Button(txt).Build()
 						}
 
-						if bs.Input != iv.ivy.Base().Input {
+						if bs.Input != iv.worksheet.DefaultBase().Input {
 							iv.inputBaseBadge.Push()
 							defer iv.inputBaseBadge.Pop()
 							txt := fmt.Sprintf("%d", bs.Input)
@@ -353,6 +772,24 @@ func (iv *ivycel) layout() {
 							giu.SetCursorScreenPos(pos)
 							giu.Button(txt).Build()
 						}
+
+						if iv.worksheet.Volatile(cell) {
+							iv.volatileBadge.Push()
+							defer iv.volatileBadge.Pop()
+							const txt = "?"
+							pos = pos.Sub(image.Point{X: int(imgui.CalcTextSize(txt).X) + badgeSpacing})
+							giu.SetCursorScreenPos(pos)
+							giu.Button(txt).Build()
+						}
+
+						if errors.Is(cell.Warning(), validation.Invalid) {
+							iv.validationBadge.Push()
+							defer iv.validationBadge.Pop()
+							const txt = "!"
+							pos = pos.Sub(image.Point{X: int(imgui.CalcTextSize(txt).X) + badgeSpacing})
+							giu.SetCursorScreenPos(pos)
+							giu.Button(txt).Build()
+						}
 					})
 				}
 
@@ -365,37 +802,62 @@ func (iv *ivycel) layout() {
 					// escape key cancels changes and deactivates the input text
 					// for the cell
 					if giu.IsKeyPressed(giu.KeyEscape) {
+						cell.Entry = iv.worksheet.User.(*worksheetUser).editOriginal
 						iv.worksheet.User.(*worksheetUser).editing = nil
 					}
 
+					// F2 switches between point mode and moving the edit cursor
+					if giu.IsKeyPressed(giu.KeyF2) {
+						iv.worksheet.User.(*worksheetUser).pointMode = !iv.worksheet.User.(*worksheetUser).pointMode
+					}
+
 					// CalbackAlways flag so we can update the editCursorPosition every keypress
 					// and EnterReturnsTrue so that OnChange() is not triggered until editing
-					// has finished
-					celInp.Flags(giu.InputTextFlagsCallbackAlways | giu.InputTextFlagsEnterReturnsTrue)
+					// has finished. the autocomplete flags include CallbackAlways
+					celInp.Flags(giu.InputTextFlagsEnterReturnsTrue | autocompleteFlags)
 
 					// keep track of current cursor position in the input
 					// widget. we use this to insert cell references at the
 					// correct point
 					celInp.Callback(func(data imgui.InputTextCallbackData) int {
+						if iv.autocompleteCallback(&data) {
+							return 0
+						}
 						if iv.worksheet.User.(*worksheetUser).focusCell {
 							iv.worksheet.User.(*worksheetUser).focusCell = false
 							data.SetCursorPos(int32(cell.User.(*cellUser).editCursorPosition))
 							data.ClearSelection()
+						} else if iv.worksheet.User.(*worksheetUser).pointMode && len(iv.autocomplete.candidates) == 0 {
+							// the input widget has already moved the cursor in
+							// response to the arrow key so the cursor position
+							// from the previous update is used
+							entry, pos, ok := iv.pointReference(data.Buf(), cell.User.(*cellUser).editCursorPosition)
+							if ok {
+								data.DeleteChars(0, data.BufTextLen())
+								data.InsertChars(0, entry)
+								data.SetCursorPos(int32(pos))
+							}
 						}
 						cell.User.(*cellUser).editCursorPosition = int(data.CursorPos())
 						return 0
 					})
 
 					// on change function is only called on "enter returns true"
-					// commit changes
+					// commit changes and move the selection in the same way
+					// as the enter key does when not editing
 					celInp.OnChange(func() {
 						iv.worksheet.User.(*worksheetUser).editing = nil
-						cell.Commit(true)
-						iv.worksheet.RecalculateAll()
+						iv.commitEdit(cell)
+						if imgui.CurrentIO().KeyShift() {
+							iv.moveSelection(cells.Adjustment{Row: -1})
+						} else {
+							iv.moveSelection(cells.Adjustment{Row: 1})
+						}
 					})
 
 					rowCols = append(rowCols,
 						giu.Custom(func() {
+							iv.scrollHorizontally(rowHeaderWidth)
 							iv.cellEditStyle.Push()
 							defer iv.cellEditStyle.Pop()
 							if iv.worksheet.User.(*worksheetUser).focusCell {
@@ -406,7 +868,26 @@ func (iv *ivycel) layout() {
 								// the input cursor
 								giu.SetKeyboardFocusHere()
 							}
-							celInp.Build()
+
+							// the text of the input is made transparent so that
+							// it can be drawn with syntax highlighting
+							highlight := syntaxHighlightFits(cell.Entry, imgui.ContentRegionAvail().X)
+							if highlight {
+								giu.Style().SetColor(giu.StyleColorText, color.Transparent).To(celInp).Build()
+							} else {
+								celInp.Build()
+							}
+							iv.highlightEditReferences()
+							if highlight {
+								iv.syntaxHighlight()
+							}
+
+							// the error can only be located in the entry if the
+							// entry hasn't changed since the error happened
+							if cell.Entry == iv.worksheet.User.(*worksheetUser).editOriginal {
+								underlineError(cell, cell.Entry)
+							}
+							iv.autocompletePopup()
 							if badges != nil {
 								badges.Build()
 							}
@@ -418,11 +899,24 @@ func (iv *ivycel) layout() {
 					var cel *giu.ButtonWidget
 					var tip giu.Widget
 
-					if err := cell.Error(); err != nil {
+					// a cell that depends on a cell in error shows where the
+					// error came from. clicking on the cell selects the origin
+					var dep cells.DependencyError
+					dependsOnError := errors.As(cell.Error(), &dep)
+
+					if dependsOnError {
+						cel = giu.Button(dep.Error())
+						tip = giu.Tooltip(fmt.Sprintf("%s\n\n%s: %s", dep.Error(),
+							references.WrapCellReference(dep.Origin.Reference()), dep.Err.Error()))
+					} else if err := cell.Error(); err != nil {
 						cel = giu.Button("???")
-						tip = giu.Tooltip(err.Error())
+						tip = giu.Tooltip(errorTooltip(cell))
 					} else {
-						cel = giu.Button(cell.Result())
+						result := cell.Result()
+						if f, ok := iv.worksheet.ConditionalFormat(cell); ok && f.Icon != "" {
+							result = fmt.Sprintf("%s %s", f.Icon, result)
+						}
+						cel = giu.Button(result)
 						tip = giu.Custom(func() {})
 						if warn := cell.Warning(); warn != nil {
 							tip = giu.Tooltip(warn.Error())
@@ -438,38 +932,71 @@ func (iv *ivycel) layout() {
 					var ev *giu.EventHandler
 					ev = giu.Event()
 
+					// shift click while a cell is being edited extends the
+					// reference before the edit cursor into a range
 					ev.OnClick(giu.MouseButtonLeft, func() {
 						if iv.worksheet.User.(*worksheetUser).editing == nil {
-							iv.worksheet.User.(*worksheetUser).selected = cell
+							if dependsOnError && !imgui.CurrentIO().KeyShift() && !imgui.CurrentIO().KeyCtrl() {
+								iv.selectCell(iv.worksheet.Cell(dep.Origin.Row, dep.Origin.Column))
+							} else {
+								iv.clickCell(cell)
+							}
+						} else if imgui.CurrentIO().KeyShift() {
+							iv.extendReferenceInCellEdit(cell)
 						}
 					})
 
 					ev.OnDClick(giu.MouseButtonLeft, func() {
 						if iv.worksheet.User.(*worksheetUser).editing != nil {
 							iv.insertIntoCellEdit(references.WrapCellReference(cell.Position().Reference()))
-						} else if !cell.ReadOnly() {
-							iv.worksheet.User.(*worksheetUser).editing = cell
-							iv.worksheet.User.(*worksheetUser).focusCell = true
+						} else {
+							iv.editCell(cell, cell.Entry, false)
 						}
 					})
 
 					// decide on display style for cell
 					var sty *giu.StyleSetter
-					if cell.ReadOnly() {
+					if dependsOnError {
+						sty = iv.cellDependsOnErrorStyle
+					} else if iv.worksheet.Dirty(cell) {
+						sty = iv.cellStaleStyle
+					} else if cell.ReadOnly() {
 						sty = iv.cellReadOnlyStyle
 					} else {
 						sty = iv.cellNormalStyle
 
 					}
 
+					// the style from conditional formatting is applied over
+					// the display style
+					var cond *giu.StyleSetter
+					if f, ok := iv.worksheet.ConditionalFormat(cell); ok {
+						cond = conditionalStyle(f)
+					}
+
 					rowCols = append(rowCols,
 						giu.Custom(func() {
+							if iv.worksheet.User.(*worksheetUser).selection.Cursor() == cell.Position() {
+								iv.scrollHorizontally(rowHeaderWidth)
+							}
 							sty.Push()
 							defer sty.Pop()
+							if cond != nil {
+								cond.Push()
+								defer cond.Pop()
+							}
+							if iv.worksheet.User.(*worksheetUser).selection.Contains(cell.Position()) {
+								iv.cellSelectedStyle.Push()
+								defer iv.cellSelectedStyle.Pop()
+							} else if trc := iv.traceStyle(cell.Position()); trc != nil {
+								trc.Push()
+								defer trc.Pop()
+							}
 							giu.Row(
 								cel, iv.cellContextMenu(cell),
 								ev, tip,
 							).Build()
+							outlineReferencedCell(cell.Position(), editRefs)
 							if badges != nil {
 								badges.Build()
 							}
@@ -488,23 +1015,99 @@ func (iv *ivycel) layout() {
 	// status bar is measured during the layout directive below
 	var statusBar *giu.LabelWidget
 	{
+		var status string
 		lastErr := iv.ivy.LastError()
 		if lastErr == nil {
-			statusBar = giu.Label("Ready")
+			switch {
+			case iv.worksheet.User.(*worksheetUser).editing == nil:
+				status = "Ready"
+			case iv.worksheet.User.(*worksheetUser).pointMode:
+				status = "Point"
+			default:
+				status = "Edit"
+			}
 		} else {
-			statusBar = giu.Label(lastErr.Error())
+			status = lastErr.Error()
+		}
+
+		// an edit rejected by validation takes the place of the normal status
+		if iv.rejected != nil && iv.worksheet.User.(*worksheetUser).editing == nil {
+			status = iv.rejected.Error()
+		}
+
+		// as does an undo that failed because a cell has changed
+		if iv.undoFailed != nil && iv.worksheet.User.(*worksheetUser).editing == nil {
+			status = iv.undoFailed.Error()
+		}
+
+		// the outcome of iterative calculation is shown if there are cells
+		// that depend on themselves
+		if res := iv.worksheet.IterationResult(); res.Iterated {
+			convergence := "converged"
+			if !res.Converged {
+				convergence = "not converged"
+			}
+			status = fmt.Sprintf("%s  (%d iterations, %s)", status, res.Iterations, convergence)
 		}
+
+		// in manual calculation mode the number of stale cells is shown
+		if n := iv.worksheet.DirtyCount(); n > 0 {
+			status = fmt.Sprintf("%s  (%d stale cells, press F9 to calculate)", status, n)
+		}
+
+		statusBar = giu.Label(status)
 	}
 
-	giu.SingleWindowWithMenuBar().Layout(
+	// windows can be docked around the edges of the main window. the main
+	// window fills the central node of the dock space and can't itself be
+	// docked with
+	dock := imgui.DockSpaceOverViewportV(imgui.MainViewport(), imgui.DockNodeFlagsPassthruCentralNode, nil)
+	mainWindow := giu.SingleWindowWithMenuBar().
+		Flags(giu.WindowFlagsNoTitleBar | giu.WindowFlagsNoCollapse | giu.WindowFlagsNoScrollbar |
+			giu.WindowFlagsNoMove | giu.WindowFlagsMenuBar | giu.WindowFlagsNoResize |
+			giu.WindowFlagsNoBringToFrontOnFocus | giu.WindowFlags(imgui.WindowFlagsNoDocking))
+	if central := imgui.InternalDockBuilderGetCentralNode(dock); central != nil {
+		mainWindow.Pos(central.Pos().X, central.Pos().Y).Size(central.Size().X, central.Size().Y)
+	}
+
+	mainWindow.Layout(
 		giu.MenuBar().Layout(
 			giu.Spacing(),
 			giu.Menu(string(fonts.FileMenu)).Layout(
 				giu.Label("File"),
 				giu.Separator(),
-				giu.MenuItem("Open..."),
-				giu.MenuItem("Save..."),
+				giu.MenuItem("Open...").OnClick(func() {
+					iv.file.err = nil
+					iv.file.save = false
+					iv.file.open = true
+					iv.file.active = true
+				}),
+				giu.MenuItem("Save...").OnClick(func() {
+					iv.file.err = nil
+					iv.file.save = true
+					iv.file.open = true
+					iv.file.active = true
+				}),
+				giu.Separator(),
+				giu.MenuItem("Load Binary File...").OnClick(func() {
+					iv.hexDump.err = nil
+					iv.hexDump.open = true
+					iv.hexDump.active = true
+				}),
+				giu.MenuItem("Export Range...").OnClick(func() {
+					iv.export.rng = iv.worksheet.User.(*worksheetUser).selection.Active().Reference()
+					iv.export.problems = iv.export.problems[:0]
+					iv.export.err = nil
+					iv.export.open = true
+					iv.export.active = true
+				}),
+				giu.MenuItem("Export Dependency Graph...").OnClick(func() {
+					iv.dotExport.err = nil
+					iv.dotExport.open = true
+					iv.dotExport.active = true
+				}),
 			),
+			iv.worksheetMenu(),
 		),
 		giu.Style().SetFontSize(fonts.WorksheetFontSize).To(
 			giu.Row(
@@ -521,6 +1124,13 @@ func (iv *ivycel) layout() {
 						defer giu.Style().SetDisabled(true).Pop()
 					}
 					formula.Build()
+
+					// the entry is restored if the edit is rejected by
+					// validation
+					if imgui.IsItemActivated() {
+						iv.worksheet.User.(*worksheetUser).editOriginal = iv.worksheet.User.(*worksheetUser).selected.Entry
+					}
+					iv.autocompletePopup()
 				}),
 			),
 			worksheet,
@@ -535,7 +1145,26 @@ func (iv *ivycel) layout() {
 		giu.Custom(func() {
 			iv.statusBarHeight = giu.GetCursorScreenPos().Y - iv.statusBarHeight
 		}),
+
+		iv.customBaseModal(),
+		iv.settingsModal(),
+		iv.seedModal(),
+		iv.iterationModal(),
+		iv.goalSeekModal(),
+		iv.validationModal(),
+		iv.layoutEditorModal(),
+		iv.reassembleBytesModal(),
+		iv.hexDumpModal(),
+		iv.exportModal(),
+		iv.dotExportModal(),
+		iv.fileModal(),
 	)
+
+	iv.inspector()
+	iv.traceWindow()
+	iv.dataTableWindow()
+	iv.scenariosWindow()
+	iv.formattingWindow()
 }
 
 func (iv *ivycel) setStyling() {
@@ -551,6 +1180,30 @@ func (iv *ivycel) setStyling() {
 		SetStyle(giu.StyleVarButtonTextAlign, 0, 0).
 		SetColor(giu.StyleColorButton, color.Transparent)
 
+	col := color.RGBA{R: 60, G: 60, B: 120, A: 255}
+	iv.cellSelectedStyle = giu.Style().
+		SetColor(giu.StyleColorButton, col)
+
+	col = color.RGBA{R: 40, G: 90, B: 140, A: 255}
+	iv.cellPrecedentStyle = giu.Style().
+		SetColor(giu.StyleColorButton, col)
+
+	col = color.RGBA{R: 140, G: 90, B: 40, A: 255}
+	iv.cellDependentStyle = giu.Style().
+		SetColor(giu.StyleColorButton, col)
+
+	iv.cellDependsOnErrorStyle = giu.Style().
+		SetStyleFloat(giu.StyleVarFrameBorderSize, 0).
+		SetStyleFloat(giu.StyleVarFrameRounding, 0).
+		SetStyle(giu.StyleVarButtonTextAlign, 0, 0).
+		SetColor(giu.StyleColorText, color.RGBA{R: 255, G: 160, B: 80, A: 255})
+
+	iv.cellStaleStyle = giu.Style().
+		SetStyleFloat(giu.StyleVarFrameBorderSize, 0).
+		SetStyleFloat(giu.StyleVarFrameRounding, 0).
+		SetStyle(giu.StyleVarButtonTextAlign, 0, 0).
+		SetColor(giu.StyleColorText, color.RGBA{R: 140, G: 140, B: 140, A: 255})
+
 	iv.cellEditStyle = giu.Style().
 		SetStyleFloat(giu.StyleVarFrameBorderSize, 2).
 		SetStyleFloat(giu.StyleVarFrameRounding, 3).
@@ -560,7 +1213,7 @@ func (iv *ivycel) setStyling() {
 		SetFontSize(fonts.ContextMenuFontSize)
 
 	vcol := imgui.CurrentStyle().Colors()[giu.StyleColorTableRowBg]
-	col := color.RGBA{R: uint8(vcol.X), G: uint8(vcol.Y), B: uint8(vcol.Z), A: uint8(vcol.W)}
+	col = color.RGBA{R: uint8(vcol.X), G: uint8(vcol.Y), B: uint8(vcol.Z), A: uint8(vcol.W)}
 
 	iv.headerStyle = giu.Style().
 		SetFontSize(fonts.WorksheetHeaderSize).
@@ -588,6 +1241,18 @@ func (iv *ivycel) setStyling() {
 		SetColor(giu.StyleColorButton, col).
 		SetColor(giu.StyleColorButtonActive, col).
 		SetColor(giu.StyleColorButtonHovered, col)
+
+	col = color.RGBA{R: 100, G: 200, B: 100, A: 200}
+	iv.volatileBadge = giu.Style().
+		SetColor(giu.StyleColorButton, col).
+		SetColor(giu.StyleColorButtonActive, col).
+		SetColor(giu.StyleColorButtonHovered, col)
+
+	col = color.RGBA{R: 230, G: 160, B: 40, A: 200}
+	iv.validationBadge = giu.Style().
+		SetColor(giu.StyleColorButton, col).
+		SetColor(giu.StyleColorButtonActive, col).
+		SetColor(giu.StyleColorButtonHovered, col)
 }
 
 func (iv *ivycel) setFonts() {
@@ -600,22 +1265,26 @@ func (iv *ivycel) setFonts() {
 }
 
 func main() {
-	iv := ivycel{
-		ivy: ivy.New(),
-	}
+	eng := ivy.New()
 
-	addCellUser := func(cell *cells.Cell) {
-		cell.User = &cellUser{}
+	iv := ivycel{
+		ivy: &eng,
+		hexDump: hexDump{
+			bytesPerRow: 16,
+		},
+		export: export{
+			name: "table",
+		},
 	}
 
-	iv.worksheet = worksheet.NewWorksheet(&iv.ivy, 100, 100, addCellUser)
-	iv.worksheet.User = &worksheetUser{
-		selected:     iv.worksheet.Cell(0, 0),
-		focusFormula: true,
-	}
+	iv.worksheet = worksheet.NewWorksheet(iv.ivy, 100, 100, addCellUser)
+	iv.worksheet.User = newWorksheetUser(iv.worksheet)
 
 	wnd := giu.NewMasterWindow("Ivycel", 800, 600, 0)
 
+	// docking allows the inspector window to be attached to the main window
+	imgui.CurrentIO().SetConfigFlags(imgui.CurrentIO().ConfigFlags() | imgui.ConfigFlagsDockingEnable)
+
 	iv.setFonts()
 	iv.setStyling()
 
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	imgui "github.com/AllenDang/cimgui-go"
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// colours used to highlight the references in the cell being edited. colours
+// are used in order as new references are found in the entry
+var referenceColours = []color.RGBA{
+	{R: 100, G: 150, B: 255, A: 255},
+	{R: 255, G: 100, B: 100, A: 255},
+	{R: 100, G: 220, B: 100, A: 255},
+	{R: 220, G: 120, B: 255, A: 255},
+	{R: 255, G: 180, B: 60, A: 255},
+	{R: 60, G: 220, B: 220, A: 255},
+}
+
+// a reference in the cell being edited and the colour it is highlighted with
+type editReference struct {
+	loc references.Location
+	col color.RGBA
+}
+
+// the references in the entry of the cell being edited. references to the
+// same cell or range are given the same colour
+func (iv *ivycel) editReferences() []editReference {
+	editCell := iv.worksheet.User.(*worksheetUser).editing
+	if editCell == nil {
+		return nil
+	}
+
+	var refs []editReference
+	colours := make(map[cells.Range]color.RGBA)
+	for _, loc := range references.FindReferences(editCell.Entry) {
+		col, ok := colours[loc.Range]
+		if !ok {
+			col = referenceColours[len(colours)%len(referenceColours)]
+			colours[loc.Range] = col
+		}
+		refs = append(refs, editReference{loc: loc, col: col})
+	}
+	return refs
+}
+
+// highlight each reference in the cell being edited. this must be called
+// immediately after the input widget for the cell has been built
+func (iv *ivycel) highlightEditReferences() {
+	entry := iv.worksheet.User.(*worksheetUser).editing.Entry
+	rmin := imgui.ItemRectMin()
+	rmax := imgui.ItemRectMax()
+	pad := imgui.CurrentStyle().FramePadding()
+	canvas := giu.GetCanvas()
+
+	for _, r := range iv.editReferences() {
+		x0 := rmin.X + pad.X + imgui.CalcTextSize(entry[:r.loc.Start]).X
+		x1 := rmin.X + pad.X + imgui.CalcTextSize(entry[:r.loc.End]).X
+		if x0 >= rmax.X {
+			continue
+		}
+		x1 = min(x1, rmax.X)
+
+		col := r.col
+		col.A = 80
+		canvas.AddRectFilled(
+			image.Pt(int(x0), int(rmin.Y+pad.Y/2)),
+			image.Pt(int(x1), int(rmax.Y-pad.Y/2)),
+			col, 2, giu.DrawFlagsRoundCornersAll)
+	}
+}
+
+// outline the cell if it is referred to by the cell being edited. a cell in a
+// range reference is outlined only on the edges of the range. this must be
+// called immediately after the widget for the cell has been built
+func outlineReferencedCell(p cells.Position, refs []editReference) {
+	for _, r := range refs {
+		rng := r.loc.Range
+		if !rng.Contains(p) {
+			continue
+		}
+
+		rmin := imgui.ItemRectMin()
+		rmax := imgui.ItemRectMax()
+		tl := image.Pt(int(rmin.X), int(rmin.Y))
+		tr := image.Pt(int(rmax.X), int(rmin.Y))
+		bl := image.Pt(int(rmin.X), int(rmax.Y))
+		br := image.Pt(int(rmax.X), int(rmax.Y))
+
+		const thickness = 2
+		canvas := giu.GetCanvas()
+		if p.Row == rng.Start.Row {
+			canvas.AddLine(tl, tr, r.col, thickness)
+		}
+		if p.Row == rng.End.Row {
+			canvas.AddLine(bl, br, r.col, thickness)
+		}
+		if p.Column == rng.Start.Column {
+			canvas.AddLine(tl, bl, r.col, thickness)
+		}
+		if p.Column == rng.End.Column {
+			canvas.AddLine(tr, br, r.col, thickness)
+		}
+		return
+	}
+}
+
+// in point mode the arrow keys move the reference immediately before the
+// cursor to a neighbouring cell. with shift held, the reference is extended
+// into a range instead. the new entry and cursor position are returned
+func (iv *ivycel) pointReference(entry string, cursor int) (string, int, bool) {
+	var adj cells.Adjustment
+	switch {
+	case giu.IsKeyPressed(giu.KeyUp):
+		adj.Row = -1
+	case giu.IsKeyPressed(giu.KeyDown):
+		adj.Row = 1
+	case giu.IsKeyPressed(giu.KeyLeft):
+		adj.Column = -1
+	case giu.IsKeyPressed(giu.KeyRight):
+		adj.Column = 1
+	default:
+		return entry, cursor, false
+	}
+
+	rows, columns := iv.worksheet.Size()
+	origin := iv.worksheet.User.(*worksheetUser).editing.Position()
+	return references.PointReference(entry, cursor, origin, adj, imgui.CurrentIO().KeyShift(), rows, columns)
+}
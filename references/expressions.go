@@ -1,6 +1,7 @@
 package references
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/jetsetilly/ivycel/cells"
@@ -20,37 +21,48 @@ import (
 //
 // in case of error the unadjusted expression is returned
 func AdjustCellReferencesInExpression(expression string, adj func(cells.Position) cells.Adjustment) (string, error) {
-
-	mtchs := CellReferenceMatch.FindAllStringSubmatch(expression, -1)
-
-	type mapping struct {
-		from string
-		to   string
+	adjust := func(ref string) (string, error) {
+		p, err := cells.PositionFromReference(ref)
+		if err != nil {
+			return ref, err
+		}
+		return AdjustCellReference(ref, adj(p))
 	}
 
-	var replacements []mapping
+	var err error
 
-	for _, m := range mtchs {
-		ref := m[referenceWithoutIndex]
-		p, err := cells.PositionFromReference(ref)
+	// each reference is replaced in place. replacing by string search would
+	// cause problems with expressions like "{A1} + {A10}" where the first
+	// reference is a prefix of the second
+	r := CellReferenceMatch.ReplaceAllStringFunc(expression, func(m string) string {
 		if err != nil {
-			return expression, err
+			return m
 		}
+		sm := CellReferenceMatch.FindStringSubmatch(m)
 
-		adjRef, err := AdjustCellReference(ref, adj(p))
+		var ref string
+		ref, err = adjust(sm[referenceWithoutIndex])
 		if err != nil {
-			return expression, err
+			return m
 		}
 
-		replacements = append(replacements, mapping{
-			from: ref,
-			to:   strings.Replace(m[referenceWithoutIndex], ref, adjRef, 1),
-		})
-	}
+		// the remainder of the match is either the indexing or the second
+		// corner of a range reference
+		rest := strings.TrimPrefix(sm[unwrappedReference], sm[referenceWithoutIndex])
+		if RangeReferenceMatch.MatchString(m) {
+			rest = strings.TrimPrefix(rest, ":")
+			rest, err = adjust(rest)
+			if err != nil {
+				return m
+			}
+			rest = fmt.Sprintf(":%s", rest)
+		}
 
-	for _, r := range replacements {
-		expression = strings.Replace(expression, r.from, r.to, -1)
+		return WrapCellReference(fmt.Sprintf("%s%s", ref, rest))
+	})
+	if err != nil {
+		return expression, err
 	}
 
-	return expression, nil
+	return r, nil
 }
@@ -51,6 +51,16 @@ func TestExpressionsAdjustment(t *testing.T) {
 			from: "{A1} + {B100[1]} / {ZZZ2309[100][203]}",
 			to:   "{B2} + {C101[1]} / {AAAA2310[100][203]}",
 		},
+		{
+			// a reference that is a prefix of another reference
+			from: "{A1} + {A10}",
+			to:   "{B2} + {B11}",
+		},
+		{
+			// both corners of a range are adjusted
+			from: "+/{A1:B10}",
+			to:   "+/{B2:C11}",
+		},
 	}
 
 	adj := func(_ cells.Position) cells.Adjustment {
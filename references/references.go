@@ -3,6 +3,8 @@ package references
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"unicode"
 
 	"github.com/jetsetilly/ivycel/cells"
 )
@@ -37,6 +39,17 @@ func WrapCellReference(ref string) string {
 	return fmt.Sprintf("{%s}", ref)
 }
 
+// SelfReference is a reference to the cell that an expression is being
+// evaluated for. it is used in expressions that apply to more than one cell,
+// such as conditional formatting rules
+const SelfReference = "{.}"
+
+// replace every self reference in the expression with a reference to the
+// cell. the reference should not be wrapped
+func ReplaceSelfReference(ex string, ref string) string {
+	return strings.ReplaceAll(ex, SelfReference, WrapCellReference(ref))
+}
+
 // adjust cell reference by provided adjustment. cell references should not be
 // wrapped. in case of error the unadjusted reference is returned
 func AdjustCellReference(ref string, adj cells.Adjustment) (string, error) {
@@ -59,3 +72,141 @@ var EngineReferenceMatch = regexp.MustCompile("__([[:alpha:]]+[[:digit:]]+)")
 func EngineToCellReference(msg string) string {
 	return EngineReferenceMatch.ReplaceAllString(msg, "{$1}")
 }
+
+// match a reference to a named field in a cell. for example, "{A1.MODE}". the
+// field name must begin with a letter or underscore
+var FieldReferenceMatch = regexp.MustCompile("{([[:alpha:]]+[[:digit:]]+)\\.([[:alpha:]_][[:alnum:]_]*)}")
+
+// replace all field references in the expression with the string returned by
+// the expand function. the expand function takes the unwrapped cell reference
+// and the field name. in case of error the unexpanded expression is returned
+func ExpandFieldReferences(ex string, expand func(ref string, field string) (string, error)) (string, error) {
+	var err error
+	r := FieldReferenceMatch.ReplaceAllStringFunc(ex, func(m string) string {
+		if err != nil {
+			return m
+		}
+		sm := FieldReferenceMatch.FindStringSubmatch(m)
+		var s string
+		s, err = expand(sm[1], sm[2])
+		return s
+	})
+	if err != nil {
+		return ex, err
+	}
+	return r, nil
+}
+
+// match a reference to a rectangular range of cells. for example, "{A1:B5}"
+var RangeReferenceMatch = regexp.MustCompile("{([[:alpha:]]+[[:digit:]]+):([[:alpha:]]+[[:digit:]]+)}")
+
+// replace all range references in the expression with the string returned by
+// the expand function. the expand function takes the unwrapped references of
+// the two corners of the range. in case of error the unexpanded expression is
+// returned
+func ExpandRangeReferences(ex string, expand func(start string, end string) (string, error)) (string, error) {
+	var err error
+	r := RangeReferenceMatch.ReplaceAllStringFunc(ex, func(m string) string {
+		if err != nil {
+			return m
+		}
+		sm := RangeReferenceMatch.FindStringSubmatch(m)
+		var s string
+		s, err = expand(sm[1], sm[2])
+		return s
+	})
+	if err != nil {
+		return ex, err
+	}
+	return r, nil
+}
+
+// Location is the position of a cell or range reference in an expression. the
+// start and end are byte offsets and include the braces. a reference to a
+// single cell results in a range of one cell
+type Location struct {
+	Start int
+	End   int
+	Range cells.Range
+}
+
+// FindReferences returns the location of every cell, field and range reference
+// in the expression in the order that they appear. references to illegal cell
+// positions are ignored
+func FindReferences(ex string) []Location {
+	var locs []Location
+	for _, m := range CellReferenceMatch.FindAllStringSubmatchIndex(ex, -1) {
+		var rng cells.Range
+		var err error
+		if sm := RangeReferenceMatch.FindStringSubmatch(ex[m[0]:m[1]]); sm != nil {
+			rng, err = cells.RangeFromReference(fmt.Sprintf("%s:%s", sm[1], sm[2]))
+		} else {
+			rng, err = cells.RangeFromReference(ex[m[2*referenceWithoutIndex]:m[2*referenceWithoutIndex+1]])
+		}
+		if err != nil {
+			continue
+		}
+		locs = append(locs, Location{Start: m[0], End: m[1], Range: rng})
+	}
+	return locs
+}
+
+// match a cell or range reference at the end of a string
+var TrailingReferenceMatch = regexp.MustCompile("{([[:alpha:]]+[[:digit:]]+)(:[[:alpha:]]+[[:digit:]]+)?}$")
+
+// characters after which point mode will insert a new reference
+const pointModeOperators = "+-*/()[],<>=!&|^%~?:"
+
+// PointReference moves the reference immediately before the cursor in the
+// entry by the adjustment. if extend is true the reference is extended into a
+// range instead. if there is no reference before the cursor then a reference
+// to the cell next to the origin is inserted, but only if the cursor follows
+// an operator or a space. positions are limited to a worksheet of the
+// specified size. the new entry and cursor position are returned
+func PointReference(entry string, cursor int, origin cells.Position, adj cells.Adjustment,
+	extend bool, rows int, columns int) (string, int, bool) {
+	cursor = min(cursor, len(entry))
+	before := entry[:cursor]
+
+	var start, end cells.Position
+	var at int
+
+	if m := TrailingReferenceMatch.FindStringSubmatchIndex(before); m != nil {
+		var err error
+		start, err = cells.PositionFromReference(before[m[2]:m[3]])
+		if err != nil {
+			return entry, cursor, false
+		}
+		end = start
+		if m[4] != -1 {
+			end, err = cells.PositionFromReference(before[m[4]+1 : m[5]])
+			if err != nil {
+				return entry, cursor, false
+			}
+		}
+
+		if extend {
+			end = end.Adjust(adj).Clamp(rows, columns)
+		} else {
+			start = end.Adjust(adj).Clamp(rows, columns)
+			end = start
+		}
+		at = m[0]
+	} else {
+		t := strings.TrimRightFunc(before, unicode.IsSpace)
+		if t != "" && t == before && !strings.ContainsAny(t[len(t)-1:], pointModeOperators) {
+			return entry, cursor, false
+		}
+		start = origin.Adjust(adj).Clamp(rows, columns)
+		end = start
+		at = cursor
+	}
+
+	ref := start.Reference()
+	if end != start {
+		ref = fmt.Sprintf("%s:%s", ref, end.Reference())
+	}
+	ref = WrapCellReference(ref)
+
+	return fmt.Sprintf("%s%s%s", before[:at], ref, entry[cursor:]), at + len(ref), true
+}
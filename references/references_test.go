@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/jetsetilly/ivycel/cells"
 	"github.com/jetsetilly/ivycel/references"
 )
 
@@ -166,3 +167,139 @@ func TestEngineReference(t *testing.T) {
 	msg = references.EngineToCellReference("__A1 + __A2")
 	ExpectEquality(t, msg, "{A1} + {A2}")
 }
+
+func TestFieldReference(t *testing.T) {
+	var ok bool
+
+	ok = references.FieldReferenceMatch.MatchString("{A1.MODE}")
+	ExpectEquality(t, ok, true)
+	ok = references.FieldReferenceMatch.MatchString("{AB12._x1}")
+	ExpectEquality(t, ok, true)
+
+	ok = references.FieldReferenceMatch.MatchString("{A1}")
+	ExpectEquality(t, ok, false)
+	ok = references.FieldReferenceMatch.MatchString("{A1.}")
+	ExpectEquality(t, ok, false)
+	ok = references.FieldReferenceMatch.MatchString("{A1.1X}")
+	ExpectEquality(t, ok, false)
+	ok = references.FieldReferenceMatch.MatchString("{A1. MODE}")
+	ExpectEquality(t, ok, false)
+
+	// field references are also cell references
+	ok = references.CellReferenceMatch.MatchString("{A1.MODE}")
+	ExpectEquality(t, ok, true)
+
+	expand := func(ref string, field string) (string, error) {
+		if field == "BAD" {
+			return "", fmt.Errorf("no field %s", field)
+		}
+		return fmt.Sprintf("(%s %s)", ref, field), nil
+	}
+
+	s, err := references.ExpandFieldReferences("{A1.MODE} + {B2} * {C3.DIV}", expand)
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, s, "(A1 MODE) + {B2} * (C3 DIV)")
+
+	s, err = references.ExpandFieldReferences("{A1.MODE} + {C3.BAD}", expand)
+	ExpectEquality(t, err != nil, true)
+	ExpectEquality(t, s, "{A1.MODE} + {C3.BAD}")
+}
+
+func TestRangeReference(t *testing.T) {
+	var ok bool
+
+	ok = references.RangeReferenceMatch.MatchString("{A1:B5}")
+	ExpectEquality(t, ok, true)
+	ok = references.RangeReferenceMatch.MatchString("{A1}")
+	ExpectEquality(t, ok, false)
+	ok = references.RangeReferenceMatch.MatchString("{A1:B}")
+	ExpectEquality(t, ok, false)
+	ok = references.RangeReferenceMatch.MatchString("{A1 :B5}")
+	ExpectEquality(t, ok, false)
+
+	expand := func(start string, end string) (string, error) {
+		if start == end {
+			return "", fmt.Errorf("single cell range")
+		}
+		return fmt.Sprintf("(%s to %s)", start, end), nil
+	}
+
+	s, err := references.ExpandRangeReferences("+/{A1:A5} * {B2}", expand)
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, s, "+/(A1 to A5) * {B2}")
+
+	s, err = references.ExpandRangeReferences("{A1:B2} + {C3:C3}", expand)
+	ExpectEquality(t, err != nil, true)
+	ExpectEquality(t, s, "{A1:B2} + {C3:C3}")
+}
+
+func TestFindReferences(t *testing.T) {
+	ex := "{B3}*{C7[1]}+{D2:E4} - {A1.MODE} + {A0}"
+	locs := references.FindReferences(ex)
+	ExpectEquality(t, len(locs), 4)
+
+	ExpectEquality(t, ex[locs[0].Start:locs[0].End], "{B3}")
+	ExpectEquality(t, locs[0].Range.String(), "B3")
+	ExpectEquality(t, ex[locs[1].Start:locs[1].End], "{C7[1]}")
+	ExpectEquality(t, locs[1].Range.String(), "C7")
+	ExpectEquality(t, ex[locs[2].Start:locs[2].End], "{D2:E4}")
+	ExpectEquality(t, locs[2].Range.String(), "D2:E4")
+	ExpectEquality(t, ex[locs[3].Start:locs[3].End], "{A1.MODE}")
+	ExpectEquality(t, locs[3].Range.String(), "A1")
+}
+
+func TestSelfReference(t *testing.T) {
+	ExpectEquality(t, references.ReplaceSelfReference("{.} > 255", "B3"), "{B3} > 255")
+	ExpectEquality(t, references.ReplaceSelfReference("({.} and 1) == {.}[1]", "A1"), "({A1} and 1) == {A1}[1]")
+	ExpectEquality(t, references.ReplaceSelfReference("{A2} + 1", "A1"), "{A2} + 1")
+
+	// the self reference is not a cell reference
+	ExpectEquality(t, references.CellReferenceMatch.MatchString(references.SelfReference), false)
+}
+
+func TestPointReference(t *testing.T) {
+	origin := cells.Position{Row: 1, Column: 1}
+	right := cells.Adjustment{Column: 1}
+	down := cells.Adjustment{Row: 1}
+
+	// a reference to the cell next to the origin is inserted after an operator
+	s, c, ok := references.PointReference("1+", 2, origin, right, false, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "1+{C2}")
+	ExpectEquality(t, c, 6)
+
+	// and at the start of the entry
+	s, c, ok = references.PointReference("", 0, origin, down, false, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "{B3}")
+	ExpectEquality(t, c, 4)
+
+	// but not after a number
+	s, _, ok = references.PointReference("12", 2, origin, right, false, 10, 10)
+	ExpectEquality(t, ok, false)
+	ExpectEquality(t, s, "12")
+
+	// the reference before the cursor is moved
+	s, c, ok = references.PointReference("1+{C2}*2", 6, origin, down, false, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "1+{C3}*2")
+	ExpectEquality(t, c, 6)
+
+	// or extended into a range
+	s, _, ok = references.PointReference("1+{C2}", 6, origin, down, true, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "1+{C2:C3}")
+	s, _, ok = references.PointReference("1+{C2:C3}", 9, origin, right, true, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "1+{C2:D3}")
+
+	// a range reference collapses to the cell next to the end of the range
+	s, _, ok = references.PointReference("{C2:D3}", 7, origin, right, false, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "{E3}")
+
+	// references can't move outside of the worksheet
+	s, _, ok = references.PointReference("{A1}", 4, origin, cells.Adjustment{Row: -1}, false, 10, 10)
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, s, "{A1}")
+}
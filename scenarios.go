@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+// state of the scenarios window
+type scenarios struct {
+	name string
+
+	// the output cells for the summary as a comma separated list of
+	// references and ranges
+	outputs string
+
+	// the most recent summary
+	summaryOutputs   []*cells.Cell
+	summaryScenarios []string
+	summary          [][]string
+
+	err error
+
+	// the scenarios window is open
+	active bool
+}
+
+// the cells in a comma separated list of references and ranges
+func (iv *ivycel) cellsFromReferences(refs string) ([]*cells.Cell, error) {
+	rows, columns := iv.worksheet.Size()
+
+	var cs []*cells.Cell
+	for _, ref := range strings.Split(refs, ",") {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue // for loop
+		}
+		rng, err := cells.RangeFromReference(ref)
+		if err != nil {
+			return nil, err
+		}
+		if rng.End.Row >= rows || rng.End.Column >= columns {
+			return nil, fmt.Errorf("{%s} is outside the worksheet", rng.Reference())
+		}
+		for row := rng.Start.Row; row <= rng.End.Row; row++ {
+			for col := rng.Start.Column; col <= rng.End.Column; col++ {
+				cs = append(cs, iv.worksheet.Cell(row, col))
+			}
+		}
+	}
+	return cs, nil
+}
+
+// fill the summary with the result of each output cell for each scenario
+func (iv *ivycel) summariseScenarios() error {
+	sc := &iv.scenarios
+	sc.summary = nil
+
+	outputs, err := iv.cellsFromReferences(sc.outputs)
+	if err != nil {
+		return err
+	}
+	if len(outputs) == 0 {
+		return errors.New("no output cells")
+	}
+
+	sc.summaryOutputs = outputs
+	sc.summaryScenarios = sc.summaryScenarios[:0]
+	for _, s := range iv.worksheet.Scenarios() {
+		sc.summaryScenarios = append(sc.summaryScenarios, s.Name)
+	}
+	sc.summary = iv.worksheet.ScenarioSummary(outputs)
+
+	return nil
+}
+
+// the scenarios window lists the scenarios in the worksheet. a scenario is
+// created from the entries of the selected cells and applying a scenario
+// changes those cells back to the saved entries
+func (iv *ivycel) scenariosWindow() {
+	sc := &iv.scenarios
+	if !sc.active {
+		return
+	}
+
+	var errLabel giu.Widget
+	if sc.err != nil {
+		errLabel = giu.Label(sc.err.Error())
+	} else {
+		errLabel = giu.Label("")
+	}
+
+	var list []*giu.TableRowWidget
+	for _, s := range iv.worksheet.Scenarios() {
+		var refs []string
+		for _, c := range iv.worksheet.ScenarioCells(s) {
+			refs = append(refs, c.Position().Reference())
+		}
+		list = append(list, giu.TableRow(
+			giu.Label(s.Name),
+			giu.Label(strings.Join(refs, ", ")),
+			giu.Row(
+				giu.Button(fmt.Sprintf("Apply##%s", s.Name)).OnClick(func() {
+					prev, err := iv.worksheet.ApplyScenario(s.Name)
+					sc.err = err
+					if len(prev) > 0 {
+						iv.pushUndo(fmt.Sprintf("Apply Scenario %s", s.Name), prev)
+					}
+				}),
+				giu.Button(fmt.Sprintf("Delete##%s", s.Name)).OnClick(func() {
+					iv.worksheet.DeleteScenario(s.Name)
+				}),
+			),
+		))
+	}
+
+	var summary giu.Widget = giu.Label("")
+	if sc.summary != nil {
+		header := []*giu.TableColumnWidget{giu.TableColumn("Cell")}
+		for _, n := range sc.summaryScenarios {
+			header = append(header, giu.TableColumn(n))
+		}
+
+		var rows []*giu.TableRowWidget
+		for o, row := range sc.summary {
+			w := []giu.Widget{giu.Label(sc.summaryOutputs[o].Position().Reference())}
+			for _, v := range row {
+				w = append(w, giu.Label(v))
+			}
+			rows = append(rows, giu.TableRow(w...))
+		}
+
+		summary = giu.Table().
+			Flags(giu.TableFlagsBorders | giu.TableFlagsRowBg).
+			Columns(header...).
+			Rows(rows...)
+	}
+
+	giu.Window("Scenarios").IsOpen(&sc.active).Size(450, 450).Layout(
+		giu.Row(
+			giu.Label("Name"),
+			giu.InputText(&sc.name).Size(150),
+			giu.Button("Save Selected Cells").OnClick(func() {
+				sc.err = iv.worksheet.SetScenario(sc.name, iv.selectedCells())
+			}),
+		),
+		errLabel,
+		giu.Table().
+			Flags(giu.TableFlagsBorders|giu.TableFlagsRowBg).
+			Size(-1, 150).
+			Columns(
+				giu.TableColumn("Scenario"),
+				giu.TableColumn("Cells"),
+				giu.TableColumn(""),
+			).
+			Rows(list...),
+		giu.Separator(),
+		giu.Row(
+			giu.Label("Output cells"),
+			giu.InputText(&sc.outputs).Size(150),
+			giu.Button("Summary").OnClick(func() {
+				sc.err = iv.summariseScenarios()
+			}),
+		),
+		summary,
+	)
+}
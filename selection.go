@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	imgui "github.com/AllenDang/cimgui-go"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// limit position to the size of the worksheet
+func (iv *ivycel) clampPosition(p cells.Position) cells.Position {
+	return p.Clamp(iv.worksheet.Size())
+}
+
+// handle a click on a cell when no cell is being edited. shift extends the
+// active range of the selection and ctrl adds a new range to the selection
+func (iv *ivycel) clickCell(cell *cells.Cell) {
+	wu := iv.worksheet.User.(*worksheetUser)
+	switch {
+	case imgui.CurrentIO().KeyShift():
+		wu.selection.Extend(cell.Position())
+	case imgui.CurrentIO().KeyCtrl():
+		wu.selected = cell
+		wu.selection.Add(cell.Position())
+	default:
+		iv.selectCell(cell)
+	}
+}
+
+// extend the active range of the selection by moving the cursor by the
+// specified amount. the selected cell does not change
+func (iv *ivycel) extendSelection(adj cells.Adjustment) {
+	wu := iv.worksheet.User.(*worksheetUser)
+	wu.selection.Extend(iv.clampPosition(wu.selection.Cursor().Adjust(adj)))
+	wu.scrollVertical = true
+	wu.scrollHorizontal = true
+}
+
+// the cells in the selection that can be changed by the user. read only cells
+// are not included
+func (iv *ivycel) selectedCells() []*cells.Cell {
+	var cs []*cells.Cell
+	for _, p := range iv.worksheet.User.(*worksheetUser).selection.Positions() {
+		cell := iv.worksheet.Cell(p.Row, p.Column)
+		if !cell.ReadOnly() {
+			cs = append(cs, cell)
+		}
+	}
+	return cs
+}
+
+// the cells that a context menu opened on the cell should apply to. if the
+// cell is part of a larger selection then the menu applies to the entire
+// selection
+func (iv *ivycel) contextCells(cell *cells.Cell) []*cells.Cell {
+	sel := iv.worksheet.User.(*worksheetUser).selection
+	if sel.IsSingle() || !sel.Contains(cell.Position()) {
+		return []*cells.Cell{cell}
+	}
+	return iv.selectedCells()
+}
+
+// copy the results of the cells in the selection to the clipboard. columns are
+// separated by tabs and rows by newlines. each range in the selection is
+// separated by an empty line
+func (iv *ivycel) copySelection() {
+	var s strings.Builder
+	for i, r := range iv.worksheet.User.(*worksheetUser).selection.Ranges() {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		for row := r.Start.Row; row <= r.End.Row; row++ {
+			for col := r.Start.Column; col <= r.End.Column; col++ {
+				if col > r.Start.Column {
+					s.WriteString("\t")
+				}
+				cell := iv.worksheet.Cell(row, col)
+				if cell.Error() == nil {
+					s.WriteString(strings.TrimSpace(cell.Result()))
+				}
+			}
+			s.WriteString("\n")
+		}
+	}
+	imgui.SetClipboardText(s.String())
+}
+
+// extend the reference immediately before the cursor in the cell being edited
+// so that it becomes a range reference ending at the cell. if there is no
+// reference before the cursor then a reference to the cell is inserted
+func (iv *ivycel) extendReferenceInCellEdit(cell *cells.Cell) {
+	editCell := iv.worksheet.User.(*worksheetUser).editing
+	pos := editCell.User.(*cellUser).editCursorPosition
+
+	ref := cell.Position().Reference()
+
+	m := references.TrailingReferenceMatch.FindStringSubmatchIndex(editCell.Entry[:pos])
+	if m == nil {
+		iv.insertIntoCellEdit(references.WrapCellReference(ref))
+		return
+	}
+
+	// the start of the range is the first cell of the existing reference
+	start := editCell.Entry[m[2]:m[3]]
+	if start != ref {
+		ref = fmt.Sprintf("%s:%s", start, ref)
+	}
+
+	editCell.Entry = fmt.Sprintf("%s%s", editCell.Entry[:m[0]], editCell.Entry[pos:])
+	editCell.User.(*cellUser).editCursorPosition = m[0]
+	iv.insertIntoCellEdit(references.WrapCellReference(ref))
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/worksheet"
+)
+
+// the origins offered by the settings dialog in the order they appear
+var settingsOrigins = []engine.Origin{
+	engine.OriginDefault,
+	engine.OriginZero,
+	engine.OriginOne,
+}
+
+// state of the engine settings dialog. the dialog is used for the settings of
+// the worksheet and for the settings of individual cells
+type settingsDialog struct {
+	title     string
+	precision int32
+	format    string
+	maxDigits int32
+	origin    int32
+	err       error
+
+	// apply is called with the settings when the dialog is confirmed. the
+	// dialog stays open if an error is returned
+	apply func(engine.Settings) error
+
+	// the dialog should be opened on the next update
+	open bool
+}
+
+// open the settings dialog with the current settings. a zero field in the
+// settings is shown as an empty or zero value
+func (iv *ivycel) openSettings(title string, current engine.Settings, apply func(engine.Settings) error) {
+	iv.settings = settingsDialog{
+		title:     title,
+		precision: int32(current.Precision),
+		format:    current.Format,
+		maxDigits: int32(current.MaxDigits),
+		apply:     apply,
+		open:      true,
+	}
+	for i, o := range settingsOrigins {
+		if o == current.Origin {
+			iv.settings.origin = int32(i)
+		}
+	}
+}
+
+// the settings dialog allows the user to change the precision, format,
+// maximum digits and origin used by the engine
+func (iv *ivycel) settingsModal() giu.Widget {
+	const popupName = "Settings"
+
+	return giu.Custom(func() {
+		sd := &iv.settings
+		if sd.apply == nil {
+			return
+		}
+
+		if sd.open {
+			sd.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var origins []string
+		for _, o := range settingsOrigins {
+			origins = append(origins, o.String())
+		}
+
+		var errLabel giu.Widget
+		if sd.err != nil {
+			errLabel = giu.Label(sd.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label(sd.title),
+			giu.Label("A zero or empty setting uses the default"),
+			giu.Spacing(),
+			giu.Row(giu.Label("Precision (bits)"), giu.InputInt(&sd.precision).Size(100)),
+			giu.Row(giu.Label("Format          "), giu.InputText(&sd.format).Size(100)),
+			giu.Row(giu.Label("Maximum digits  "), giu.InputInt(&sd.maxDigits).Size(100)),
+			giu.Row(giu.Label("Origin          "),
+				giu.Combo("##origin", origins[sd.origin], origins, &sd.origin).Size(100)),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					sd.err = sd.apply(engine.Settings{
+						Precision: int(sd.precision),
+						Format:    sd.format,
+						MaxDigits: int(sd.maxDigits),
+						Origin:    settingsOrigins[sd.origin],
+					})
+					if sd.err != nil {
+						sd.err = fmt.Errorf("settings not applied: %w", sd.err)
+						return
+					}
+					sd.apply = nil
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					sd.apply = nil
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// state of the random seed dialog
+type seedDialog struct {
+	// the seed is edited as text because the seed is a 64 bit integer
+	seed string
+	err  error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the random seed dialog changes the seed used for the random numbers in the
+// worksheet. volatile cells are recalculated with the new seed
+func (iv *ivycel) seedModal() giu.Widget {
+	const popupName = "Random Seed"
+
+	return giu.Custom(func() {
+		sd := &iv.seedDialog
+		if !sd.active {
+			return
+		}
+
+		if sd.open {
+			sd.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var errLabel giu.Widget
+		if sd.err != nil {
+			errLabel = giu.Label(sd.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label("Seed for random numbers"),
+			giu.InputText(&sd.seed).Size(200).Flags(giu.InputTextFlagsCharsDecimal),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					seed, err := strconv.ParseInt(strings.TrimSpace(sd.seed), 10, 64)
+					if err != nil {
+						sd.err = errors.New("seed must be a 64 bit integer")
+						return
+					}
+					sd.err = iv.worksheet.SetSeed(seed)
+					if sd.err != nil {
+						return
+					}
+					sd.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					sd.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// state of the iterative calculation dialog
+type iterationDialog struct {
+	enabled       bool
+	maxIterations int32
+	tolerance     float32
+	err           error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the iterative calculation dialog changes how the worksheet calculates cells
+// that depend on themselves
+func (iv *ivycel) iterationModal() giu.Widget {
+	const popupName = "Iterative Calculation"
+
+	return giu.Custom(func() {
+		id := &iv.iterationDialog
+		if !id.active {
+			return
+		}
+
+		if id.open {
+			id.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var errLabel giu.Widget
+		if id.err != nil {
+			errLabel = giu.Label(id.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Checkbox("Calculate cells that depend on themselves iteratively", &id.enabled),
+			giu.Row(giu.Label("Maximum iterations"), giu.InputInt(&id.maxIterations).Size(100)),
+			giu.Row(giu.Label("Tolerance         "), giu.InputFloat(&id.tolerance).Format("%g").Size(100)),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					id.err = iv.worksheet.SetIteration(worksheet.Iteration{
+						Enabled:       id.enabled,
+						MaxIterations: int(id.maxIterations),
+						Tolerance:     float64(id.tolerance),
+					})
+					if id.err != nil {
+						return
+					}
+					id.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					id.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
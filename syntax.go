@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	imgui "github.com/AllenDang/cimgui-go"
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+// colours used for syntax highlighting in the cell editor. references are
+// drawn in the colour chosen by editReferences()
+var syntaxColours = map[engine.TokenKind]color.RGBA{
+	engine.TokenOther:    {R: 255, G: 255, B: 255, A: 255},
+	engine.TokenNumber:   {R: 255, G: 200, B: 120, A: 255},
+	engine.TokenOperator: {R: 150, G: 180, B: 255, A: 255},
+	engine.TokenName:     {R: 255, G: 255, B: 255, A: 255},
+	engine.TokenString:   {R: 150, G: 230, B: 150, A: 255},
+	engine.TokenError:    {R: 255, G: 80, B: 80, A: 255},
+}
+
+// the colour used to underline the part of an entry that caused an error
+var errorColour = color.RGBA{R: 255, G: 80, B: 80, A: 255}
+
+// syntax highlighting is drawn over the input widget, which is built with
+// transparent text. if the entry is wider than the input widget then the widget
+// will scroll and the highlighting would no longer line up with the text. in
+// that case the input widget should be drawn normally
+func syntaxHighlightFits(entry string, width float32) bool {
+	return imgui.CalcTextSize(entry).X+imgui.CurrentStyle().FramePadding().X*2 < width
+}
+
+// draw the entry of the cell being edited with syntax highlighting. this must
+// be called immediately after the input widget for the cell has been built
+func (iv *ivycel) syntaxHighlight() {
+	wu := iv.worksheet.User.(*worksheetUser)
+	entry := wu.editing.Entry
+
+	rmin := imgui.ItemRectMin()
+	pad := imgui.CurrentStyle().FramePadding()
+	x := rmin.X + pad.X
+	y := rmin.Y + pad.Y
+	canvas := giu.GetCanvas()
+
+	draw := func(start int, end int, col color.RGBA) {
+		if start >= end {
+			return
+		}
+		sx := x + imgui.CalcTextSize(entry[:start]).X
+		canvas.AddText(image.Pt(int(sx), int(y)), col, entry[start:end])
+	}
+
+	// the colour of each byte in the entry. text that is not part of a token
+	// is drawn in the default colour
+	cols := make([]color.RGBA, len(entry))
+	for i := range cols {
+		cols[i] = syntaxColours[engine.TokenOther]
+	}
+	for _, t := range iv.ivy.Tokens(entry) {
+		for i := t.Start; i < min(t.End, len(cols)); i++ {
+			cols[i] = syntaxColours[t.Kind]
+		}
+	}
+	for _, r := range iv.editReferences() {
+		for i := r.loc.Start; i < min(r.loc.End, len(cols)); i++ {
+			cols[i] = r.col
+		}
+	}
+
+	// draw runs of the same colour together
+	var start int
+	for i := 1; i <= len(entry); i++ {
+		if i == len(entry) || cols[i] != cols[start] {
+			draw(start, i, cols[start])
+			start = i
+		}
+	}
+
+	// the input widget draws the cursor in the text colour, which has been
+	// made transparent, so the cursor must be drawn here
+	cx := x + imgui.CalcTextSize(entry[:min(wu.editing.User.(*cellUser).editCursorPosition, len(entry))]).X
+	h := imgui.CalcTextSize("X").Y
+	canvas.AddLine(image.Pt(int(cx), int(y)), image.Pt(int(cx), int(y+h)), syntaxColours[engine.TokenOther], 1)
+}
+
+// underline the part of the entry that caused the error in the cell. this must
+// be called immediately after the input widget for the cell has been built
+func underlineError(cell *cells.Cell, entry string) {
+	start, end, ok := engine.ErrorSpan(entry, cell.Error())
+	if !ok {
+		return
+	}
+
+	rmin := imgui.ItemRectMin()
+	rmax := imgui.ItemRectMax()
+	pad := imgui.CurrentStyle().FramePadding()
+	x0 := rmin.X + pad.X + imgui.CalcTextSize(entry[:start]).X
+	x1 := rmin.X + pad.X + imgui.CalcTextSize(entry[:end]).X
+	if x0 >= rmax.X {
+		return
+	}
+	x1 = min(x1, rmax.X)
+
+	y := rmax.Y - pad.Y/2
+	giu.GetCanvas().AddLine(image.Pt(int(x0), int(y)), image.Pt(int(x1), int(y)), errorColour, 2)
+}
+
+// the text of the tooltip for a cell with an error. if the location of the
+// error in the entry is known then the entry is shown with the location marked
+func errorTooltip(cell *cells.Cell) string {
+	err := cell.Error()
+	start, end, ok := engine.ErrorSpan(cell.Entry, err)
+	if !ok {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s\n\n%s\n%s%s", err.Error(), cell.Entry,
+		strings.Repeat(" ", start), strings.Repeat("^", end-start))
+}
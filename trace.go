@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+// the direction of a trace through the dependency graph
+type traceKind int
+
+const (
+	tracePrecedents traceKind = iota
+	traceDependents
+)
+
+func (k traceKind) String() string {
+	switch k {
+	case tracePrecedents:
+		return "Precedents"
+	case traceDependents:
+		return "Dependents"
+	}
+	panic("unknown trace kind")
+}
+
+// state of the trace window. the traced cells are highlighted in the worksheet
+// while the window is open
+type trace struct {
+	kind traceKind
+	from cells.Position
+
+	positions []cells.Position
+	traced    map[cells.Position]bool
+
+	// the generation of the worksheet when the trace was last updated
+	generation int
+
+	// the trace window is open
+	active bool
+}
+
+// start tracing the precedents or dependents of the cell
+func (iv *ivycel) traceCell(cell *cells.Cell, kind traceKind) {
+	iv.trace = trace{
+		kind:       kind,
+		from:       cell.Position(),
+		generation: -1,
+		active:     true,
+	}
+	iv.updateTrace()
+}
+
+// update the traced cells. the dependency graph is rebuilt whenever a cell
+// has been committed so that the trace follows changes to the worksheet
+func (iv *ivycel) updateTrace() {
+	tr := &iv.trace
+	if !tr.active {
+		return
+	}
+
+	generation := iv.worksheet.Generation()
+	if generation == tr.generation {
+		return
+	}
+	tr.generation = generation
+
+	g := iv.worksheet.Dependencies()
+	switch tr.kind {
+	case tracePrecedents:
+		tr.positions = g.Precedents(tr.from)
+	case traceDependents:
+		tr.positions = g.Dependents(tr.from)
+	}
+
+	tr.traced = make(map[cells.Position]bool)
+	for _, p := range tr.positions {
+		tr.traced[p] = true
+	}
+}
+
+// the style for a cell that is part of the current trace. returns nil if the
+// cell is not part of the trace
+func (iv *ivycel) traceStyle(p cells.Position) *giu.StyleSetter {
+	if !iv.trace.active || !iv.trace.traced[p] {
+		return nil
+	}
+	switch iv.trace.kind {
+	case tracePrecedents:
+		return iv.cellPrecedentStyle
+	case traceDependents:
+		return iv.cellDependentStyle
+	}
+	return nil
+}
+
+// the trace window lists the traced cells. clicking on a cell in the list
+// selects it in the worksheet
+func (iv *ivycel) traceWindow() {
+	tr := &iv.trace
+	if !tr.active {
+		return
+	}
+
+	var list []giu.Widget
+	for _, p := range tr.positions {
+		cell := iv.worksheet.Cell(p.Row, p.Column)
+		list = append(list, giu.Selectable(fmt.Sprintf("%-6s %s", p.Reference(), cell.Entry)).
+			Selected(iv.worksheet.User.(*worksheetUser).selected == cell).
+			OnClick(func() {
+				iv.selectCell(cell)
+			}))
+	}
+	if len(list) == 0 {
+		list = append(list, giu.Label("None"))
+	}
+
+	giu.Window("Trace").IsOpen(&tr.active).Size(250, 300).Layout(
+		giu.Label(fmt.Sprintf("%s of %s", tr.kind, tr.from.Reference())),
+		giu.Separator(),
+		giu.Column(list...),
+	)
+}
+
+// state of the dependency graph export dialog
+type dotExport struct {
+	filename string
+	err      error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// the dependency graph export dialog writes the dependency graph of the
+// worksheet to a file in the Graphviz DOT language
+func (iv *ivycel) dotExportModal() giu.Widget {
+	const popupName = "Export Dependency Graph"
+
+	return giu.Custom(func() {
+		if !iv.dotExport.active {
+			return
+		}
+
+		if iv.dotExport.open {
+			iv.dotExport.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		ex := &iv.dotExport
+
+		var errLabel giu.Widget
+		if ex.err != nil {
+			errLabel = giu.Label(ex.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label("Filename"),
+			giu.InputText(&ex.filename).Size(300),
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					ex.err = iv.exportDOT()
+					if ex.err != nil {
+						return
+					}
+					ex.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					ex.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// write the dependency graph to the file named in the export dialog
+func (iv *ivycel) exportDOT() error {
+	if iv.dotExport.filename == "" {
+		return errors.New("no filename")
+	}
+	return os.WriteFile(iv.dotExport.filename, []byte(iv.worksheet.Dependencies().DOT("ivycel")), 0o644)
+}
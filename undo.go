@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+// the maximum number of steps that can be undone
+const maxUndo = 50
+
+// a change to the entries of cells that can be undone
+type undoStep struct {
+	label string
+
+	// the entries of the cells before and after the change
+	before map[*cells.Cell]string
+	after  map[*cells.Cell]string
+}
+
+// record a change to the entries of the cells. the entries are the entries of
+// the cells before the change and the function must be called after the change
+// has been made. the label describes the change and is shown in the menu
+func (iv *ivycel) pushUndo(label string, entries map[*cells.Cell]string) {
+	after := make(map[*cells.Cell]string)
+	for cell := range entries {
+		after[cell] = cell.Entry
+	}
+	iv.undoSteps = append(iv.undoSteps, undoStep{label: label, before: entries, after: after})
+	if len(iv.undoSteps) > maxUndo {
+		iv.undoSteps = iv.undoSteps[1:]
+	}
+}
+
+// the label of the step that will be undone next. returns the empty string if
+// there is nothing to undo
+func (iv *ivycel) undoLabel() string {
+	if len(iv.undoSteps) == 0 {
+		return ""
+	}
+	return iv.undoSteps[len(iv.undoSteps)-1].label
+}
+
+// restore the entries of the cells in the most recent step. the step is
+// discarded without changing anything if any of the cells has been edited since
+// the change and the reason is shown in the status bar
+func (iv *ivycel) undo() {
+	iv.undoFailed = nil
+	if len(iv.undoSteps) == 0 {
+		return
+	}
+	step := iv.undoSteps[len(iv.undoSteps)-1]
+	iv.undoSteps = iv.undoSteps[:len(iv.undoSteps)-1]
+
+	var changed []string
+	for cell, entry := range step.after {
+		if cell.Entry != entry {
+			changed = append(changed, cell.Position().Reference())
+		}
+	}
+	if len(changed) > 0 {
+		slices.Sort(changed)
+		iv.undoFailed = fmt.Errorf("can't undo %s: %s changed since", step.label, strings.Join(changed, ", "))
+		return
+	}
+
+	for cell, entry := range step.before {
+		cell.Entry = entry
+		cell.Commit(true)
+	}
+	iv.worksheet.RecalculateAll()
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AllenDang/giu"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/references"
+	"github.com/jetsetilly/ivycel/validation"
+)
+
+// the kinds and actions offered by the validation dialog in the order they
+// appear
+var validationKinds = []validation.Kind{
+	validation.KindList,
+	validation.KindRange,
+	validation.KindInteger,
+	validation.KindBitWidth,
+	validation.KindCustom,
+}
+
+var validationActions = []validation.Action{
+	validation.ActionReject,
+	validation.ActionWarn,
+}
+
+// state of the validation dialog
+type validationDialog struct {
+	title   string
+	targets []*cells.Cell
+
+	kind       int32
+	action     int32
+	values     string
+	min        string
+	max        string
+	bits       int32
+	signed     bool
+	expression string
+	err        error
+
+	// the dialog should be opened on the next update
+	open bool
+
+	// the dialog is active and should be drawn
+	active bool
+}
+
+// open the validation dialog for the cells. the dialog shows the validation
+// rule of the first cell
+func (iv *ivycel) openValidation(title string, targets []*cells.Cell) {
+	iv.validation = validationDialog{
+		title:      title,
+		targets:    targets,
+		bits:       8,
+		expression: fmt.Sprintf("%s > 0", references.SelfReference),
+		open:       true,
+		active:     true,
+	}
+
+	r, ok := iv.worksheet.Validation(targets[0])
+	if !ok {
+		return
+	}
+
+	vd := &iv.validation
+	for i, k := range validationKinds {
+		if k == r.Kind {
+			vd.kind = int32(i)
+		}
+	}
+	for i, a := range validationActions {
+		if a == r.Action {
+			vd.action = int32(i)
+		}
+	}
+	vd.values = strings.Join(r.Values, ", ")
+	vd.min = r.Min
+	vd.max = r.Max
+	if r.Bits > 0 {
+		vd.bits = int32(r.Bits)
+	}
+	vd.signed = r.Signed
+	if r.Expression != "" {
+		vd.expression = r.Expression
+	}
+}
+
+// the rule described by the dialog
+func (vd validationDialog) rule() validation.Rule {
+	r := validation.Rule{
+		Kind:   validationKinds[vd.kind],
+		Action: validationActions[vd.action],
+	}
+	switch r.Kind {
+	case validation.KindList:
+		for _, v := range strings.Split(vd.values, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				r.Values = append(r.Values, v)
+			}
+		}
+	case validation.KindRange:
+		r.Min = strings.TrimSpace(vd.min)
+		r.Max = strings.TrimSpace(vd.max)
+	case validation.KindBitWidth:
+		r.Bits = int(vd.bits)
+		r.Signed = vd.signed
+	case validation.KindCustom:
+		r.Expression = strings.TrimSpace(vd.expression)
+	}
+	return r
+}
+
+// the validation dialog sets or removes the validation rule for the cells
+func (iv *ivycel) validationModal() giu.Widget {
+	const popupName = "Validation"
+
+	return giu.Custom(func() {
+		vd := &iv.validation
+		if !vd.active {
+			return
+		}
+
+		if vd.open {
+			vd.open = false
+			giu.OpenPopup(popupName)
+		}
+
+		var kinds []string
+		for _, k := range validationKinds {
+			kinds = append(kinds, k.String())
+		}
+		var actions []string
+		for _, a := range validationActions {
+			actions = append(actions, a.String())
+		}
+
+		// the fields shown depend on the kind of rule
+		var fields giu.Widget
+		switch validationKinds[vd.kind] {
+		case validation.KindList:
+			fields = giu.Column(
+				giu.Row(giu.Label("Values    "), giu.InputText(&vd.values).Size(250)),
+				giu.Label("Values are separated by commas"),
+			)
+		case validation.KindRange:
+			fields = giu.Column(
+				giu.Row(giu.Label("Minimum   "), giu.InputText(&vd.min).Size(250)),
+				giu.Row(giu.Label("Maximum   "), giu.InputText(&vd.max).Size(250)),
+				giu.Label("An empty limit means there is no limit"),
+			)
+		case validation.KindInteger:
+			fields = giu.Label("Every value must be an integer")
+		case validation.KindBitWidth:
+			fields = giu.Column(
+				giu.Row(giu.Label("Bits      "), giu.InputInt(&vd.bits).Size(100)),
+				giu.Checkbox("Signed", &vd.signed),
+			)
+		case validation.KindCustom:
+			fields = giu.Column(
+				giu.Row(giu.Label("Expression"), giu.InputText(&vd.expression).Size(250)),
+				giu.Label(fmt.Sprintf("%s refers to the cell being validated", references.SelfReference)),
+			)
+		}
+
+		var errLabel giu.Widget
+		if vd.err != nil {
+			errLabel = giu.Label(vd.err.Error())
+		} else {
+			errLabel = giu.Label("")
+		}
+
+		giu.PopupModal(popupName).Flags(giu.WindowFlagsAlwaysAutoResize).Layout(
+			giu.Label(vd.title),
+			giu.Spacing(),
+			giu.Row(giu.Label("Rule      "),
+				giu.Combo("##kind", kinds[vd.kind], kinds, &vd.kind).Size(250)),
+			giu.Row(giu.Label("On failure"),
+				giu.Combo("##action", actions[vd.action], actions, &vd.action).Size(250)),
+			giu.Spacing(),
+			fields,
+			errLabel,
+			giu.Row(
+				giu.Button("OK").OnClick(func() {
+					if vd.err = iv.worksheet.SetValidation(vd.rule(), vd.targets...); vd.err != nil {
+						return
+					}
+					vd.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Remove").OnClick(func() {
+					iv.worksheet.DeleteValidation(vd.targets...)
+					vd.active = false
+					giu.CloseCurrentPopup()
+				}),
+				giu.Button("Cancel").OnClick(func() {
+					vd.active = false
+					giu.CloseCurrentPopup()
+				}),
+			),
+		).Build()
+	})
+}
+
+// commit the entry of a cell that has been edited by the user. an entry that
+// is rejected by the validation rule for the cell is replaced by the entry from
+// before the edit and the reason is shown in the status bar
+func (iv *ivycel) commitEdit(cell *cells.Cell) {
+	iv.rejected = nil
+	iv.undoFailed = nil
+	if err := iv.worksheet.CommitEdit(cell, iv.worksheet.User.(*worksheetUser).editOriginal); err != nil {
+		iv.rejected = fmt.Errorf("%s: %w", cell.Position().Reference(), err)
+	}
+}
+
+// a short description of the validation rule for the inspector
+func validationSummary(r validation.Rule, ok bool) string {
+	if !ok {
+		return "none"
+	}
+	return fmt.Sprintf("%s (%s)", r.Kind, strings.ToLower(r.Action.String()))
+}
@@ -0,0 +1,212 @@
+// Package validation checks the value of a cell against a rule chosen by the
+// user.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var Invalid = errors.New("invalid value")
+var Rejected = errors.New("entry rejected")
+var InvalidRule = errors.New("invalid validation rule")
+
+// Kind is the type of check made by a validation rule
+type Kind int
+
+const (
+	// the value must be one of a list of values
+	KindList Kind = iota
+
+	// the value must be between a minimum and a maximum
+	KindRange
+
+	// the value must be an integer
+	KindInteger
+
+	// the value must be an integer that fits in a number of bits
+	KindBitWidth
+
+	// the value must make an expression true
+	KindCustom
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindList:
+		return "List of values"
+	case KindRange:
+		return "Range"
+	case KindInteger:
+		return "Integer"
+	case KindBitWidth:
+		return "Bit width"
+	case KindCustom:
+		return "Custom expression"
+	}
+	panic("unknown validation kind")
+}
+
+// Action is what happens to an entry that fails validation
+type Action int
+
+const (
+	// the entry is not accepted when it is entered. a value that fails
+	// validation later, because of a change to another cell, is given a
+	// warning
+	ActionReject Action = iota
+
+	// the entry is accepted but the cell is given a warning
+	ActionWarn
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionReject:
+		return "Reject"
+	case ActionWarn:
+		return "Warn"
+	}
+	panic("unknown validation action")
+}
+
+// Rule is a validation rule for a cell. only the fields for the kind of rule
+// are used. numbers in the rule are decimal or have a 0x, 0o or 0b prefix
+type Rule struct {
+	Kind   Kind
+	Action Action
+
+	// the allowed values for KindList
+	Values []string
+
+	// the inclusive limits for KindRange. an empty limit means that there is
+	// no limit
+	Min string
+	Max string
+
+	// the number of bits for KindBitWidth. a signed value is in two's
+	// complement form
+	Bits   int
+	Signed bool
+
+	// the expression for KindCustom. the self reference in the expression
+	// refers to the cell being validated
+	Expression string
+}
+
+// parse a number in a rule or in the exact value of a cell
+func parseNumber(s string) (*big.Rat, bool) {
+	if i, ok := new(big.Int).SetString(s, 0); ok {
+		return new(big.Rat).SetInt(i), true
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// Check returns an error if the rule is not valid
+func (r Rule) Check() error {
+	switch r.Kind {
+	case KindList:
+		if len(r.Values) == 0 {
+			return fmt.Errorf("%w: no values in the list", InvalidRule)
+		}
+	case KindRange:
+		if r.Min == "" && r.Max == "" {
+			return fmt.Errorf("%w: no minimum or maximum", InvalidRule)
+		}
+		for _, l := range []string{r.Min, r.Max} {
+			if _, ok := parseNumber(l); l != "" && !ok {
+				return fmt.Errorf("%w: %s is not a number", InvalidRule, l)
+			}
+		}
+	case KindBitWidth:
+		if r.Bits < 1 {
+			return fmt.Errorf("%w: bit width must be at least one", InvalidRule)
+		}
+	case KindCustom:
+		if strings.TrimSpace(r.Expression) == "" {
+			return fmt.Errorf("%w: no expression", InvalidRule)
+		}
+	}
+	return nil
+}
+
+// Validate checks the value against the rule. the value should be the exact
+// value of the cell, with the elements of a vector or matrix separated by
+// spaces. every element must pass the rule. the evaluate function is called
+// with the expression of a custom rule and should return true if the
+// expression is true for the cell
+func (r Rule) Validate(value string, evaluate func(ex string) (bool, error)) error {
+	if r.Kind == KindCustom {
+		ok, err := evaluate(r.Expression)
+		if err != nil {
+			return fmt.Errorf("%w: %w", Invalid, err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s is false", Invalid, r.Expression)
+		}
+		return nil
+	}
+
+	for _, v := range strings.Fields(value) {
+		if err := r.validateElement(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r Rule) validateElement(v string) error {
+	n, isNumber := parseNumber(v)
+
+	switch r.Kind {
+	case KindList:
+		for _, a := range r.Values {
+			if an, ok := parseNumber(a); ok && isNumber {
+				if an.Cmp(n) == 0 {
+					return nil
+				}
+			} else if strings.TrimSpace(a) == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s is not one of %s", Invalid, v, strings.Join(r.Values, ", "))
+
+	case KindRange:
+		if !isNumber {
+			return fmt.Errorf("%w: %s is not a number", Invalid, v)
+		}
+		if lo, ok := parseNumber(r.Min); ok && n.Cmp(lo) < 0 {
+			return fmt.Errorf("%w: %s is less than %s", Invalid, v, r.Min)
+		}
+		if hi, ok := parseNumber(r.Max); ok && n.Cmp(hi) > 0 {
+			return fmt.Errorf("%w: %s is greater than %s", Invalid, v, r.Max)
+		}
+
+	case KindInteger:
+		if !isNumber || !n.IsInt() {
+			return fmt.Errorf("%w: %s is not an integer", Invalid, v)
+		}
+
+	case KindBitWidth:
+		if !isNumber || !n.IsInt() {
+			return fmt.Errorf("%w: %s is not an integer", Invalid, v)
+		}
+		lo := new(big.Int)
+		hi := new(big.Int).Lsh(big.NewInt(1), uint(r.Bits))
+		if r.Signed {
+			hi.Rsh(hi, 1)
+			lo.Neg(hi)
+		}
+		if n.Num().Cmp(lo) < 0 || n.Num().Cmp(hi) >= 0 {
+			signed := "unsigned"
+			if r.Signed {
+				signed = "signed"
+			}
+			return fmt.Errorf("%w: %s does not fit in %d bits %s", Invalid, v, r.Bits, signed)
+		}
+	}
+
+	return nil
+}
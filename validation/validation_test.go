@@ -0,0 +1,95 @@
+package validation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/validation"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("expected error '%v' but got '%v'", expected, err)
+	}
+}
+
+// evaluate function for rules that aren't custom rules
+func noEvaluate(_ string) (bool, error) {
+	panic("evaluate should not be called")
+}
+
+func TestList(t *testing.T) {
+	r := validation.Rule{Kind: validation.KindList, Values: []string{"1", "0x10", "'a'"}}
+	ExpectEquality(t, r.Check(), nil)
+	ExpectEquality(t, r.Validate("1", noEvaluate), nil)
+	ExpectEquality(t, r.Validate("16", noEvaluate), nil)
+	ExpectEquality(t, r.Validate("1 16", noEvaluate), nil)
+	ExpectEquality(t, r.Validate("'a'", noEvaluate), nil)
+	ExpectedError(t, r.Validate("2", noEvaluate), validation.Invalid)
+	ExpectedError(t, r.Validate("1 2", noEvaluate), validation.Invalid)
+
+	r.Values = nil
+	ExpectedError(t, r.Check(), validation.InvalidRule)
+}
+
+func TestRange(t *testing.T) {
+	r := validation.Rule{Kind: validation.KindRange, Min: "-1", Max: "2.5"}
+	ExpectEquality(t, r.Check(), nil)
+	ExpectEquality(t, r.Validate("-1", noEvaluate), nil)
+	ExpectEquality(t, r.Validate("5/2", noEvaluate), nil)
+	ExpectedError(t, r.Validate("3", noEvaluate), validation.Invalid)
+	ExpectedError(t, r.Validate("-1.5", noEvaluate), validation.Invalid)
+	ExpectedError(t, r.Validate("'a'", noEvaluate), validation.Invalid)
+
+	// no maximum
+	r.Max = ""
+	ExpectEquality(t, r.Validate("1000000", noEvaluate), nil)
+
+	r.Min = "x"
+	ExpectedError(t, r.Check(), validation.InvalidRule)
+}
+
+func TestInteger(t *testing.T) {
+	r := validation.Rule{Kind: validation.KindInteger}
+	ExpectEquality(t, r.Validate("-12 4", noEvaluate), nil)
+	ExpectEquality(t, r.Validate("4/2", noEvaluate), nil)
+	ExpectedError(t, r.Validate("1/3", noEvaluate), validation.Invalid)
+	ExpectedError(t, r.Validate("0.5", noEvaluate), validation.Invalid)
+}
+
+func TestBitWidth(t *testing.T) {
+	r := validation.Rule{Kind: validation.KindBitWidth, Bits: 8}
+	ExpectEquality(t, r.Validate("0 255", noEvaluate), nil)
+	ExpectedError(t, r.Validate("256", noEvaluate), validation.Invalid)
+	ExpectedError(t, r.Validate("-1", noEvaluate), validation.Invalid)
+
+	r.Signed = true
+	ExpectEquality(t, r.Validate("-128 127", noEvaluate), nil)
+	ExpectedError(t, r.Validate("128", noEvaluate), validation.Invalid)
+	ExpectedError(t, r.Validate("-129", noEvaluate), validation.Invalid)
+
+	r.Bits = 0
+	ExpectedError(t, r.Check(), validation.InvalidRule)
+}
+
+func TestCustom(t *testing.T) {
+	r := validation.Rule{Kind: validation.KindCustom, Expression: "{.} > 2"}
+	ExpectEquality(t, r.Validate("3", func(ex string) (bool, error) {
+		ExpectEquality(t, ex, "{.} > 2")
+		return true, nil
+	}), nil)
+	ExpectedError(t, r.Validate("1", func(string) (bool, error) {
+		return false, nil
+	}), validation.Invalid)
+	ExpectedError(t, r.Validate("1", func(string) (bool, error) {
+		return false, errors.New("bad expression")
+	}), validation.Invalid)
+}
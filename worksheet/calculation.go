@@ -0,0 +1,300 @@
+package worksheet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+)
+
+var InvalidIteration = errors.New("invalid iterative calculation setting")
+
+// Calculation is how the worksheet is recalculated after a change
+type Calculation int
+
+const (
+	// every cell is recalculated after a change
+	CalculationAutomatic Calculation = iota
+
+	// cells that depend on a change are marked as dirty and are only
+	// recalculated when Calculate() is called
+	CalculationManual
+)
+
+func (c Calculation) String() string {
+	switch c {
+	case CalculationAutomatic:
+		return "Automatic"
+	case CalculationManual:
+		return "Manual"
+	}
+	panic("unknown calculation mode")
+}
+
+// Calculation returns the calculation mode of the worksheet
+func (ws Worksheet) Calculation() Calculation {
+	return ws.calculation
+}
+
+// SetCalculation changes the calculation mode of the worksheet. changing to
+// automatic calculation recalculates the worksheet
+func (ws *Worksheet) SetCalculation(c Calculation) {
+	ws.calculation = c
+	if c == CalculationAutomatic {
+		ws.RecalculateAll()
+	}
+}
+
+// Dirty returns true if the cell depends on a change that has not yet been
+// calculated. a cell can only be dirty in manual calculation mode
+func (ws Worksheet) Dirty(cell *cells.Cell) bool {
+	return ws.dirty[cell.ID()]
+}
+
+// DirtyCount returns the number of dirty cells in the worksheet
+func (ws Worksheet) DirtyCount() int {
+	return len(ws.dirty)
+}
+
+// Calculate commits every dirty cell in the order given by the dependency
+// graph. volatile cells are not committed
+func (ws Worksheet) Calculate() {
+	include := func(cell *cells.Cell) bool {
+		return ws.dirty[cell.ID()] && !ws.Volatile(cell)
+	}
+
+	order, cycles, names := ws.calculationOrder()
+	ws.withNames(names, func() {
+		ws.engine.WithErrorSupression(func() {
+			ws.commitInOrder(order, cycles, include)
+		})
+	})
+	ws.updateCalculated()
+	ws.updateFormats()
+}
+
+// the state of a cell that is used to decide whether the cell has changed
+// since it was last calculated
+func calculatedState(cell *cells.Cell) string {
+	return fmt.Sprintf("%s\x00%v", cell.Result(), cell.Error())
+}
+
+// record the state of every cell and clear the dirty flags
+func (ws Worksheet) updateCalculated() {
+	clear(ws.dirty)
+	for id, cell := range ws.cellsByID {
+		ws.calculated[id] = calculatedState(cell)
+	}
+}
+
+// every cell that has changed since it was last calculated marks the cells
+// that depend on it as dirty. the changed cell is no longer dirty because it
+// must have been committed for it to change
+func (ws Worksheet) markDirty() {
+	g := ws.Dependencies()
+	for id, cell := range ws.cellsByID {
+		s := calculatedState(cell)
+		prev, ok := ws.calculated[id]
+		ws.calculated[id] = s
+
+		// a cell that hasn't been seen before is a new cell and nothing can
+		// depend on it yet
+		if !ok || prev == s {
+			continue // for loop
+		}
+
+		delete(ws.dirty, id)
+		for _, p := range g.Dependents(ws.positions[id]) {
+			if dep := ws.cellsByPosition[p]; dep != id {
+				ws.dirty[dep] = true
+			}
+		}
+	}
+}
+
+// Iteration controls the iterative calculation of cells that depend on
+// themselves
+type Iteration struct {
+	Enabled bool
+
+	// the maximum number of times that the cells in a cycle are committed
+	MaxIterations int
+
+	// the cells in a cycle have converged when no value changes by more than
+	// the tolerance between iterations
+	Tolerance float64
+}
+
+// DefaultIteration is the iteration setting for a new worksheet
+var DefaultIteration = Iteration{
+	MaxIterations: 100,
+	Tolerance:     0.001,
+}
+
+// IterationResult is the outcome of the most recent calculation of the cells
+// that depend on themselves
+type IterationResult struct {
+	// false if iterative calculation is disabled or if there were no cells
+	// that depend on themselves
+	Iterated bool
+
+	// the largest number of iterations needed by any cycle
+	Iterations int
+
+	Converged bool
+}
+
+// returns an InvalidIteration error if the setting can't be used
+func (it Iteration) check() error {
+	if it.MaxIterations < 1 {
+		return fmt.Errorf("%w: maximum iterations must be at least one", InvalidIteration)
+	}
+	if it.Tolerance < 0 {
+		return fmt.Errorf("%w: tolerance must not be negative", InvalidIteration)
+	}
+	return nil
+}
+
+// Iteration returns the iterative calculation setting of the worksheet
+func (ws Worksheet) Iteration() Iteration {
+	return ws.iteration
+}
+
+// SetIteration changes the iterative calculation setting of the worksheet
+// and recalculates the worksheet
+func (ws *Worksheet) SetIteration(it Iteration) error {
+	if err := it.check(); err != nil {
+		return err
+	}
+	ws.iteration = it
+	ws.recalculateWorksheet()
+	return nil
+}
+
+// IterationResult returns the outcome of the most recent calculation of the
+// cells that depend on themselves
+func (ws Worksheet) IterationResult() IterationResult {
+	return *ws.iterationResult
+}
+
+// commit the cells in the order given by the dependency graph. the cells in a
+// cycle are committed together when the last of them is reached in the order.
+// the cells that the cycle depends on come before that point and the cells that
+// depend on the cycle come after it. cells are only committed if the include
+// function returns true
+func (ws Worksheet) commitInOrder(order []cells.Position, cycles [][]cells.Position, include func(*cells.Cell) bool) {
+	*ws.iterationResult = IterationResult{}
+
+	inCycle := make(map[cells.Position]bool)
+	last := make(map[cells.Position][]cells.Position)
+	for _, cycle := range cycles {
+		for _, p := range cycle {
+			inCycle[p] = true
+		}
+
+		// the cells in a cycle are in the same order as the order of the
+		// worksheet
+		last[cycle[len(cycle)-1]] = cycle
+	}
+
+	for _, p := range order {
+		if cycle, ok := last[p]; ok {
+			ws.iterate(cycle, include)
+			continue // for loop
+		}
+		if inCycle[p] {
+			continue // for loop
+		}
+		if cell := ws.cellsByID[ws.cellsByPosition[p]]; include(cell) {
+			cell.Commit(false)
+		}
+	}
+}
+
+// commit the cells in the cycle repeatedly until the values of the cells
+// converge and add the outcome to the iteration result. if iterative
+// calculation is disabled then the cells are committed once and given a
+// CircularReference warning instead. cells are only committed if the include
+// function returns true
+func (ws Worksheet) iterate(cycle []cells.Position, include func(*cells.Cell) bool) {
+	var cs []*cells.Cell
+	for _, p := range cycle {
+		if cell := ws.cellsByID[ws.cellsByPosition[p]]; include(cell) {
+			cs = append(cs, cell)
+		}
+	}
+	if len(cs) == 0 {
+		return
+	}
+
+	if !ws.iteration.Enabled {
+		for _, cell := range cs {
+			cell.Commit(false)
+			cell.AddWarning(cells.CircularReference)
+		}
+		return
+	}
+
+	res := ws.iterationResult
+	if !res.Iterated {
+		*res = IterationResult{Iterated: true, Converged: true}
+	}
+
+	n, unconverged := ws.iterateCycle(cs)
+	res.Iterations = max(res.Iterations, n)
+	for _, cell := range unconverged {
+		res.Converged = false
+		cell.AddWarning(fmt.Errorf("%w after %d iterations", cells.NotConverged, n))
+	}
+}
+
+// commit the cells repeatedly until no value changes by more than the
+// tolerance or until the maximum number of iterations has been reached.
+// returns the number of iterations and the cells that did not converge
+func (ws Worksheet) iterateCycle(cs []*cells.Cell) (int, []*cells.Cell) {
+	exact := func(cell *cells.Cell) string {
+		v, err := ws.engine.ExactValue(cell.Position().Reference())
+		if err != nil {
+			return ""
+		}
+		return v
+	}
+
+	values := make([]string, len(cs))
+	for i, cell := range cs {
+		values[i] = exact(cell)
+	}
+
+	var unconverged []*cells.Cell
+	for n := 1; n <= ws.iteration.MaxIterations; n++ {
+		unconverged = unconverged[:0]
+		for i, cell := range cs {
+			cell.Commit(false)
+			v := exact(cell)
+			if engine.Difference(values[i], v) > ws.iteration.Tolerance {
+				unconverged = append(unconverged, cell)
+			}
+			values[i] = v
+		}
+		if len(unconverged) == 0 {
+			return n, nil
+		}
+	}
+
+	return ws.iteration.MaxIterations, unconverged
+}
+
+// DependentCells returns every cell that depends on the cell, in the order
+// that they should be committed. the cell itself is not included even if it
+// depends on itself
+func (ws Worksheet) DependentCells(cell *cells.Cell) []*cells.Cell {
+	g := ws.Dependencies()
+	var cs []*cells.Cell
+	for _, p := range g.Order(g.Dependents(cell.Position())) {
+		if dep := ws.cellsByID[ws.cellsByPosition[p]]; dep != cell {
+			cs = append(cs, dep)
+		}
+	}
+	return cs
+}
@@ -0,0 +1,164 @@
+package worksheet
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+var SpilledDependency = errors.New("formula depends on the input through a spilled result")
+var VolatileDependency = errors.New("formula depends on the input through a volatile cell")
+
+// execute the entry for the cell with the engine. the cell itself is not
+// changed. the base and settings of the cell are used
+func (ws Worksheet) executeFor(cell *cells.Cell, entry string) error {
+	var err error
+	settingsErr := ws.engine.WithSettings(cell.Settings(), func() {
+		baseErr := ws.engine.WithNumberBase(cell.Base(), func() {
+			var ex string
+			ex, err = ws.ExpandReferences(entry, cell.Base().Input)
+			if err != nil {
+				return
+			}
+			_, err = ws.engine.Execute(cell.Position().Reference(), ex)
+		})
+		if baseErr != nil {
+			err = baseErr
+		}
+	})
+	if settingsErr != nil {
+		return settingsErr
+	}
+	return err
+}
+
+// WhatIf returns the result of the formula cell with the entries of the input
+// cells replaced by the values. the inputs and the cells that depend on them
+// are executed by the engine with the new values and then executed again with
+// their own entries. the cells themselves are never changed. an input can't be
+// part of the result of another cell
+func (ws Worksheet) WhatIf(formula *cells.Cell, inputs []*cells.Cell, values []string) (string, error) {
+	if len(inputs) != len(values) {
+		return "", fmt.Errorf("%d values for %d inputs", len(values), len(inputs))
+	}
+	if slices.Contains(inputs, formula) {
+		return "", fmt.Errorf("{%s} is an input", formula.Position().Reference())
+	}
+
+	// the engine variable for a child cell can't be restored by executing the
+	// entry of the child because the value comes from the parent
+	for _, in := range inputs {
+		if in.ReadOnly() {
+			return "", fmt.Errorf("{%s} is part of the result of another cell", in.Position().Reference())
+		}
+	}
+
+	// every cell that depends on an input in the order they should be
+	// executed. inputs that depend on another input are not included
+	g := ws.Dependencies()
+	var ps []cells.Position
+	for _, in := range inputs {
+		ps = append(ps, g.Dependents(in.Position())...)
+	}
+	var deps []*cells.Cell
+	for _, p := range g.Order(ps) {
+		cell := ws.Cell(p.Row, p.Column)
+		if slices.Contains(deps, cell) || slices.Contains(inputs, cell) {
+			continue // for loop
+		}
+
+		// the engine variable for a child cell is only set when the parent is
+		// committed so it can't be changed by executing the parent
+		if cell.Parent() != nil {
+			if cell == formula || slices.Contains(g.Dependents(p), formula.Position()) {
+				return "", SpilledDependency
+			}
+			continue // for loop
+		}
+
+		// restoring a volatile cell would change its value
+		if ws.Volatile(cell) {
+			return "", VolatileDependency
+		}
+
+		deps = append(deps, cell)
+	}
+	if !slices.Contains(deps, formula) {
+		return "", fmt.Errorf("{%s} does not depend on the inputs", formula.Position().Reference())
+	}
+
+	var result string
+	var err error
+
+	ws.engine.WithErrorSupression(func() {
+		// restore the engine variables for the inputs and their dependents
+		defer func() {
+			for _, in := range inputs {
+				_ = ws.executeFor(in, in.Entry)
+			}
+			for _, d := range deps {
+				_ = ws.executeFor(d, d.Entry)
+			}
+		}()
+
+		for i, in := range inputs {
+			if err = ws.executeFor(in, values[i]); err != nil {
+				return
+			}
+		}
+		for _, d := range deps {
+			if err = ws.executeFor(d, d.Entry); err != nil {
+				return
+			}
+		}
+
+		settingsErr := ws.engine.WithSettings(formula.Settings(), func() {
+			baseErr := ws.engine.WithNumberBase(formula.Base(), func() {
+				result, err = ws.engine.Evaluate(references.WrapCellReference(formula.Position().Reference()))
+			})
+			if baseErr != nil {
+				err = baseErr
+			}
+		})
+		if settingsErr != nil {
+			err = settingsErr
+		}
+	})
+
+	return strings.TrimSpace(result), err
+}
+
+// DataTable returns the result of the formula cell for every combination of
+// the row values and the column values. the row values replace the entry of
+// the row input and the column values replace the entry of the column input. a
+// table with one variable has a nil column input and no column values. the
+// error for a combination is returned in place of the result
+func (ws Worksheet) DataTable(formula *cells.Cell, rowInput *cells.Cell, rowValues []string,
+	columnInput *cells.Cell, columnValues []string) [][]string {
+
+	table := make([][]string, len(rowValues))
+	for r, rv := range rowValues {
+		if columnInput == nil {
+			res, err := ws.WhatIf(formula, []*cells.Cell{rowInput}, []string{rv})
+			if err != nil {
+				res = err.Error()
+			}
+			table[r] = []string{res}
+			continue // for loop
+		}
+
+		table[r] = make([]string, len(columnValues))
+		for c, cv := range columnValues {
+			res, err := ws.WhatIf(formula, []*cells.Cell{rowInput, columnInput}, []string{rv, cv})
+			if err != nil {
+				res = err.Error()
+			}
+			table[r][c] = res
+		}
+	}
+	return table
+}
@@ -0,0 +1,324 @@
+package worksheet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/bitfields"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/validation"
+)
+
+var UnsupportedFile = errors.New("unsupported worksheet file")
+
+// the version of the worksheet file format. the version should be increased
+// whenever a change to the format means that the file can't be read by an
+// earlier version
+const fileVersion = 1
+
+// the saved form of a worksheet
+type worksheetFile struct {
+	Version int
+	Rows    int
+	Columns int
+
+	// the default base and settings of the worksheet
+	Base     engine.Base
+	Settings engine.Settings
+
+	// the seed for the random number generator
+	Seed int64
+
+	Calculation Calculation
+	Iteration   Iteration
+
+	// bitfield layouts in the form accepted by bitfields.Parse()
+	Layouts map[string]string
+
+	// only cells that have an entry or that have a property that is not the
+	// default are saved
+	Cells []cellFile
+
+	Scenarios []scenarioFile `json:",omitempty"`
+
+	// conditional formatting rules in order of priority
+	Rules []ruleFile `json:",omitempty"`
+
+	Validation []validationFile `json:",omitempty"`
+}
+
+// the saved form of a cell. the cell is identified by its reference
+type cellFile struct {
+	Cell     string
+	Entry    string           `json:",omitempty"`
+	Base     *engine.Base     `json:",omitempty"`
+	Settings *engine.Settings `json:",omitempty"`
+	Display  engine.Display   `json:",omitempty"`
+	ByteView *engine.ByteView `json:",omitempty"`
+	Layout   string           `json:",omitempty"`
+}
+
+// the saved form of a scenario. the entries are keyed by cell reference
+// because cell IDs are not saved
+type scenarioFile struct {
+	Name    string
+	Entries map[string]string
+}
+
+// the saved form of a conditional formatting rule
+type ruleFile struct {
+	Range     string
+	Condition string
+	Format    Format
+}
+
+// the saved form of the validation rule for a cell
+type validationFile struct {
+	Cell string
+	Rule validation.Rule
+}
+
+// the saved form of the cell. the ok value is false if there is nothing about
+// the cell that needs to be saved. a child cell only saves its layout because
+// everything else about a child cell comes from its parent
+func saveCell(cell *cells.Cell) (cellFile, bool) {
+	f := cellFile{
+		Cell:   cell.Position().Reference(),
+		Layout: cell.Layout(),
+	}
+
+	if cell.Parent() == nil {
+		f.Entry = cell.Entry
+		if b := cell.BaseOverride(); b != (engine.Base{}) {
+			f.Base = &b
+		}
+		if s := cell.SettingsOverride(); s != (engine.Settings{}) {
+			f.Settings = &s
+		}
+		f.Display = cell.Display()
+		if bv := cell.ByteView(); bv.Enabled() {
+			f.ByteView = &bv
+		}
+	}
+
+	return f, f != cellFile{Cell: f.Cell}
+}
+
+// Save writes the worksheet to the writer. the results of the cells are not
+// saved because they are recalculated when the worksheet is loaded
+func (ws Worksheet) Save(w io.Writer) error {
+	f := worksheetFile{
+		Version:     fileVersion,
+		Rows:        ws.size.rows,
+		Columns:     ws.size.columns,
+		Base:        ws.engine.Base(),
+		Settings:    ws.engine.Settings(),
+		Seed:        ws.seed,
+		Calculation: ws.calculation,
+		Iteration:   ws.iteration,
+		Layouts:     make(map[string]string),
+	}
+
+	for name, l := range ws.layouts {
+		f.Layouts[name] = l.String()
+	}
+
+	for rowi := range ws.size.rows {
+		for coli := range ws.size.columns {
+			if c, ok := saveCell(ws.Cell(rowi, coli)); ok {
+				f.Cells = append(f.Cells, c)
+			}
+		}
+	}
+
+	for _, sc := range ws.scenarios {
+		sf := scenarioFile{Name: sc.Name, Entries: make(map[string]string)}
+		for id, entry := range sc.Entries {
+			sf.Entries[ws.positions[id].Reference()] = entry
+		}
+		f.Scenarios = append(f.Scenarios, sf)
+	}
+
+	for _, r := range ws.rules {
+		f.Rules = append(f.Rules, ruleFile{
+			Range:     r.Range.Reference(),
+			Condition: r.Condition,
+			Format:    r.Format,
+		})
+	}
+
+	for id, r := range ws.validation {
+		f.Validation = append(f.Validation, validationFile{
+			Cell: ws.positions[id].Reference(),
+			Rule: r,
+		})
+	}
+	slices.SortFunc(f.Validation, func(a validationFile, b validationFile) int {
+		return strings.Compare(a.Cell, b.Cell)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(f)
+}
+
+// Load reads a worksheet that was written by Save(). the file is checked
+// before the engine is changed. the engine should not have been used by
+// another worksheet because variables from the other worksheet would still be
+// defined
+func Load(eng engine.Interface, r io.Reader, user User) (Worksheet, error) {
+	// the iteration setting is the default for files that don't have one
+	f := worksheetFile{Iteration: DefaultIteration}
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return Worksheet{}, fmt.Errorf("%w: %w", UnsupportedFile, err)
+	}
+	if f.Version != fileVersion {
+		return Worksheet{}, fmt.Errorf("%w: version %d", UnsupportedFile, f.Version)
+	}
+	if f.Rows < 1 || f.Columns < 1 {
+		return Worksheet{}, fmt.Errorf("%w: no rows or columns", UnsupportedFile)
+	}
+	if f.Calculation != CalculationAutomatic && f.Calculation != CalculationManual {
+		return Worksheet{}, fmt.Errorf("%w: calculation mode %d", UnsupportedFile, f.Calculation)
+	}
+	if err := f.Iteration.check(); err != nil {
+		return Worksheet{}, fmt.Errorf("%w: %w", UnsupportedFile, err)
+	}
+
+	layouts := make(map[string]bitfields.Layout)
+	for name, spec := range f.Layouts {
+		l, err := bitfields.Parse(spec)
+		if err != nil {
+			return Worksheet{}, fmt.Errorf("%w: layout %s: %w", UnsupportedFile, name, err)
+		}
+		layouts[name] = l
+	}
+
+	// the position of a cell reference in the file
+	position := func(ref string) (cells.Position, error) {
+		p, err := cells.PositionFromReference(ref)
+		if err != nil {
+			return cells.Position{}, err
+		}
+		if p.Row >= f.Rows || p.Column >= f.Columns {
+			return cells.Position{}, fmt.Errorf("%s is outside the worksheet", ref)
+		}
+		return p, nil
+	}
+
+	positions := make([]cells.Position, len(f.Cells))
+	for i, c := range f.Cells {
+		p, err := position(c.Cell)
+		if err != nil {
+			return Worksheet{}, fmt.Errorf("%w: %w", UnsupportedFile, err)
+		}
+		positions[i] = p
+	}
+
+	scenarios := make([]map[cells.Position]string, len(f.Scenarios))
+	for i, sf := range f.Scenarios {
+		scenarios[i] = make(map[cells.Position]string)
+		for ref, entry := range sf.Entries {
+			p, err := position(ref)
+			if err != nil {
+				return Worksheet{}, fmt.Errorf("%w: scenario %s: %w", UnsupportedFile, sf.Name, err)
+			}
+			scenarios[i][p] = entry
+		}
+	}
+
+	rules := make([]Rule, len(f.Rules))
+	for i, rf := range f.Rules {
+		rng, err := cells.RangeFromReference(rf.Range)
+		if err != nil {
+			return Worksheet{}, fmt.Errorf("%w: rule %d: %w", UnsupportedFile, i+1, err)
+		}
+		rules[i] = Rule{Range: rng, Condition: rf.Condition, Format: rf.Format}
+	}
+
+	validations := make([]cells.Position, len(f.Validation))
+	for i, v := range f.Validation {
+		p, err := position(v.Cell)
+		if err != nil {
+			return Worksheet{}, fmt.Errorf("%w: validation: %w", UnsupportedFile, err)
+		}
+		if err := v.Rule.Check(); err != nil {
+			return Worksheet{}, fmt.Errorf("%w: validation for %s: %w", UnsupportedFile, v.Cell, err)
+		}
+		validations[i] = p
+	}
+
+	if err := eng.SetBase(f.Base); err != nil {
+		return Worksheet{}, fmt.Errorf("%w: %w", UnsupportedFile, err)
+	}
+	if err := eng.SetSettings(f.Settings); err != nil {
+		return Worksheet{}, fmt.Errorf("%w: %w", UnsupportedFile, err)
+	}
+
+	ws := NewWorksheet(eng, f.Rows, f.Columns, user)
+	maps.Copy(ws.layouts, layouts)
+	ws.rules = rules
+
+	if err := eng.SetSeed(f.Seed); err != nil {
+		return Worksheet{}, fmt.Errorf("%w: %w", UnsupportedFile, err)
+	}
+	ws.seed = f.Seed
+	ws.iteration = f.Iteration
+
+	// the properties of every cell are set before any entry so that no cell
+	// is a child of another cell while its properties are being set
+	for i, c := range f.Cells {
+		cell := ws.Cell(positions[i].Row, positions[i].Column)
+		cell.SetLayout(c.Layout)
+		if c.Base != nil {
+			if err := cell.SetBase(*c.Base); err != nil {
+				return Worksheet{}, fmt.Errorf("%w: %s: %w", UnsupportedFile, c.Cell, err)
+			}
+		}
+		if c.Settings != nil {
+			if err := cell.SetSettings(*c.Settings); err != nil {
+				return Worksheet{}, fmt.Errorf("%w: %s: %w", UnsupportedFile, c.Cell, err)
+			}
+		}
+		if c.Display != engine.DisplayValue {
+			cell.SetDisplay(c.Display)
+		}
+		if c.ByteView != nil {
+			cell.SetByteView(*c.ByteView)
+		}
+	}
+
+	for i, c := range f.Cells {
+		ws.Cell(positions[i].Row, positions[i].Column).Entry = c.Entry
+	}
+
+	for i, v := range f.Validation {
+		ws.validation[ws.cellsByPosition[validations[i]]] = v.Rule
+	}
+
+	for i, sf := range f.Scenarios {
+		sc := Scenario{Name: sf.Name, Entries: make(map[cells.CellID]string)}
+		for p, entry := range scenarios[i] {
+			sc.Entries[ws.cellsByPosition[p]] = entry
+		}
+		ws.scenarios = append(ws.scenarios, sc)
+	}
+
+	// every cell is committed, including any volatile cells. the random
+	// number generator is reseeded with the saved seed so that the volatile
+	// cells have the values they had when the worksheet was saved
+	ws.RecalculateVolatile()
+
+	// the calculation mode is set after every cell has been committed because
+	// the results of the cells are not saved. no cell is dirty immediately
+	// after loading, even in manual calculation mode
+	ws.calculation = f.Calculation
+
+	return ws, nil
+}
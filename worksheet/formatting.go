@@ -0,0 +1,140 @@
+package worksheet
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/references"
+)
+
+// Format is how a cell is drawn when a conditional formatting rule matches the
+// cell. a colour with a zero alpha value is not used. an empty icon is not
+// used
+type Format struct {
+	Background color.RGBA
+	Text       color.RGBA
+	Icon       string
+}
+
+// Rule is a conditional formatting rule. the condition is an expression
+// evaluated for every cell in the range. the self reference in the condition
+// refers to the cell being evaluated
+type Rule struct {
+	Range     cells.Range
+	Condition string
+	Format    Format
+}
+
+// Rules returns the conditional formatting rules in order of priority
+func (ws Worksheet) Rules() []Rule {
+	return slices.Clone(ws.rules)
+}
+
+// AddRule adds a conditional formatting rule with the lowest priority
+func (ws *Worksheet) AddRule(r Rule) error {
+	r.Condition = strings.TrimSpace(r.Condition)
+	if r.Condition == "" {
+		return errors.New("rule must have a condition")
+	}
+	ws.rules = append(ws.rules, r)
+	ws.updateFormats()
+	return nil
+}
+
+// DeleteRule removes the conditional formatting rule at the index
+func (ws *Worksheet) DeleteRule(i int) {
+	if i < 0 || i >= len(ws.rules) {
+		return
+	}
+	ws.rules = slices.Delete(ws.rules, i, i+1)
+	ws.updateFormats()
+}
+
+// ConditionalFormat returns the format for the cell from the conditional
+// formatting rules that match the cell. a rule with a higher priority takes
+// precedence for each part of the format
+func (ws Worksheet) ConditionalFormat(cell *cells.Cell) (Format, bool) {
+	f, ok := ws.formats[cell.ID()]
+	return f, ok
+}
+
+// evaluate the condition for the cell. the condition is true if every element
+// of the result is non-zero. the result is reduced to a single boolean by the
+// engine so that it isn't affected by the format of the cell. numbers in the
+// condition are read in the default input base of the worksheet rather than
+// the input base of the cell so that a rule means the same for every cell in
+// its range
+func (ws Worksheet) evaluateCondition(cell *cells.Cell, condition string) (bool, error) {
+	ex := references.ReplaceSelfReference(condition, cell.Position().Reference())
+	ex = fmt.Sprintf("and/ , 0 != (%s)", ex)
+
+	settings := cell.Settings()
+	settings.Format = ""
+	base := ws.engine.Base()
+
+	var r string
+	var err error
+
+	ws.engine.WithErrorSupression(func() {
+		settingsErr := ws.engine.WithSettings(settings, func() {
+			baseErr := ws.engine.WithNumberBase(base, func() {
+				ex, err = ws.ExpandReferences(ex, base.Input)
+				if err != nil {
+					return
+				}
+				r, err = ws.engine.Evaluate(ex)
+			})
+			if baseErr != nil {
+				err = baseErr
+			}
+		})
+		if settingsErr != nil {
+			err = settingsErr
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(r) == "1", nil
+}
+
+// evaluate the conditional formatting rules for every cell. cells that are
+// empty or in error are never formatted
+func (ws Worksheet) updateFormats() {
+	clear(ws.formats)
+
+	for _, r := range ws.rules {
+		for row := r.Range.Start.Row; row <= min(r.Range.End.Row, ws.size.rows-1); row++ {
+			for col := r.Range.Start.Column; col <= min(r.Range.End.Column, ws.size.columns-1); col++ {
+				cell := ws.Cell(row, col)
+				if cell.Result() == "" || cell.Error() != nil {
+					continue // for loop
+				}
+
+				match, err := ws.evaluateCondition(cell, r.Condition)
+				if err != nil || !match {
+					continue // for loop
+				}
+
+				// rules that come first have priority so a part of the format
+				// is only set if it hasn't been set already
+				f := ws.formats[cell.ID()]
+				if f.Background.A == 0 {
+					f.Background = r.Format.Background
+				}
+				if f.Text.A == 0 {
+					f.Text = r.Format.Text
+				}
+				if f.Icon == "" {
+					f.Icon = r.Format.Icon
+				}
+				ws.formats[cell.ID()] = f
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package worksheet
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jetsetilly/ivycel/cells"
+)
+
+var NoScenario = errors.New("no such scenario")
+
+// Scenario is a named set of entries for a group of input cells. cells are
+// referred to by ID so that a scenario still applies to the same cells after
+// rows or columns have been inserted
+type Scenario struct {
+	Name    string
+	Entries map[cells.CellID]string
+}
+
+// ScenarioCells returns the cells that the scenario changes in row order
+func (ws Worksheet) ScenarioCells(sc Scenario) []*cells.Cell {
+	var cs []*cells.Cell
+	for id := range sc.Entries {
+		cs = append(cs, ws.cellsByID[id])
+	}
+	slices.SortFunc(cs, func(a *cells.Cell, b *cells.Cell) int {
+		pa := a.Position()
+		pb := b.Position()
+		if c := cmp.Compare(pa.Row, pb.Row); c != 0 {
+			return c
+		}
+		return cmp.Compare(pa.Column, pb.Column)
+	})
+	return cs
+}
+
+// Entry returns the entry that the scenario gives to the cell
+func (sc Scenario) Entry(cell *cells.Cell) (string, bool) {
+	e, ok := sc.Entries[cell.ID()]
+	return e, ok
+}
+
+// Scenarios returns every scenario in the order they were added
+func (ws Worksheet) Scenarios() []Scenario {
+	return slices.Clone(ws.scenarios)
+}
+
+// SetScenario records the current entries of the cells as a scenario. an
+// existing scenario with the same name is replaced
+func (ws *Worksheet) SetScenario(name string, cs []*cells.Cell) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("scenario must have a name")
+	}
+
+	sc := Scenario{Name: name, Entries: make(map[cells.CellID]string)}
+	for _, c := range cs {
+		if c.ReadOnly() {
+			return fmt.Errorf("{%s} is part of the result of another cell", c.Position().Reference())
+		}
+		sc.Entries[c.ID()] = c.Entry
+	}
+	if len(sc.Entries) == 0 {
+		return errors.New("scenario must have at least one cell")
+	}
+
+	i := slices.IndexFunc(ws.scenarios, func(s Scenario) bool { return s.Name == name })
+	if i >= 0 {
+		ws.scenarios[i] = sc
+	} else {
+		ws.scenarios = append(ws.scenarios, sc)
+	}
+	return nil
+}
+
+// DeleteScenario removes the named scenario. the cells are not changed
+func (ws *Worksheet) DeleteScenario(name string) {
+	ws.scenarios = slices.DeleteFunc(ws.scenarios, func(s Scenario) bool { return s.Name == name })
+}
+
+func (ws Worksheet) scenario(name string) (Scenario, error) {
+	i := slices.IndexFunc(ws.scenarios, func(s Scenario) bool { return s.Name == name })
+	if i < 0 {
+		return Scenario{}, fmt.Errorf("%w: %s", NoScenario, name)
+	}
+	return ws.scenarios[i], nil
+}
+
+// ApplyScenario changes the entries of the cells in the named scenario in the
+// same way as CommitEdit() and recalculates the worksheet. cells that are part
+// of the result of another cell are not changed and nor are cells with an
+// entry that is rejected by the validation rule for the cell. the previous
+// entries of the changed cells are returned along with any errors
+func (ws Worksheet) ApplyScenario(name string) (map[*cells.Cell]string, error) {
+	sc, err := ws.scenario(name)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := make(map[*cells.Cell]string)
+	var errs []error
+	for _, cell := range ws.ScenarioCells(sc) {
+		if cell.ReadOnly() {
+			errs = append(errs, fmt.Errorf("{%s} is part of the result of another cell", cell.Position().Reference()))
+			continue // for loop
+		}
+
+		previous := cell.Entry
+		cell.Entry = sc.Entries[cell.ID()]
+		if err := ws.commitEdit(cell, previous); err != nil {
+			errs = append(errs, fmt.Errorf("{%s}: %w", cell.Position().Reference(), err))
+			continue // for loop
+		}
+		prev[cell] = previous
+	}
+	ws.RecalculateAll()
+
+	return prev, errors.Join(errs...)
+}
+
+// commit the cells with the entries and then commit every cell that depends on
+// them. this happens whatever the calculation mode of the worksheet. volatile
+// cells are not committed unless their entry has changed
+func (ws Worksheet) commitEntries(entries map[*cells.Cell]string) {
+	var ps []cells.Position
+	g := ws.Dependencies()
+	for cell, entry := range entries {
+		// the entry of a cell that is part of the result of another cell
+		// can't be changed
+		if cell.ReadOnly() {
+			continue // for loop
+		}
+		ps = append(ps, g.Dependents(cell.Position())...)
+
+		// committing a volatile cell would change its value
+		if cell.Entry == entry && ws.Volatile(cell) {
+			continue // for loop
+		}
+		cell.Entry = entry
+		cell.Commit(true)
+	}
+	ws.engine.WithErrorSupression(func() {
+		for _, p := range g.Order(ps) {
+			if cell := ws.Cell(p.Row, p.Column); !ws.Volatile(cell) {
+				cell.Commit(false)
+			}
+		}
+	})
+}
+
+// ScenarioSummary returns the result of each output cell for each scenario.
+// there is a row for each output cell and a column for each scenario. the
+// worksheet is returned to its current state afterwards
+//
+// the cells that depend on the scenarios are committed whatever the
+// calculation mode. in manual calculation mode the dirty cells are still
+// dirty afterwards
+func (ws Worksheet) ScenarioSummary(outputs []*cells.Cell) [][]string {
+	dirty := maps.Clone(ws.dirty)
+	calculated := maps.Clone(ws.calculated)
+	defer func() {
+		clear(ws.dirty)
+		maps.Copy(ws.dirty, dirty)
+		clear(ws.calculated)
+		maps.Copy(ws.calculated, calculated)
+	}()
+
+	// the current entries of every cell changed by any scenario
+	current := make(map[*cells.Cell]string)
+	for _, sc := range ws.scenarios {
+		for id := range sc.Entries {
+			cell := ws.cellsByID[id]
+			current[cell] = cell.Entry
+		}
+	}
+
+	summary := make([][]string, len(outputs))
+	for i := range summary {
+		summary[i] = make([]string, len(ws.scenarios))
+	}
+
+	for s, sc := range ws.scenarios {
+		entries := make(map[*cells.Cell]string)
+		for cell, entry := range current {
+			entries[cell] = entry
+		}
+		for id, entry := range sc.Entries {
+			entries[ws.cellsByID[id]] = entry
+		}
+		ws.commitEntries(entries)
+
+		for o, out := range outputs {
+			if err := out.Error(); err != nil {
+				summary[o][s] = err.Error()
+			} else {
+				summary[o][s] = strings.TrimSpace(out.Result())
+			}
+		}
+	}
+
+	ws.commitEntries(current)
+
+	return summary
+}
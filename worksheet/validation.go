@@ -0,0 +1,96 @@
+package worksheet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/validation"
+)
+
+// Validation returns the validation rule for the cell
+func (ws Worksheet) Validation(cell *cells.Cell) (validation.Rule, bool) {
+	r, ok := ws.validation[cell.ID()]
+	return r, ok
+}
+
+// SetValidation sets the validation rule for each of the cells and recommits
+// the cells so that the rule is applied to the current values. the rule is
+// checked first and the cells are left unchanged if the rule is not valid. the
+// worksheet is recalculated once for all the cells or, in manual calculation
+// mode, the cells that depend on them are marked as dirty
+func (ws *Worksheet) SetValidation(r validation.Rule, cs ...*cells.Cell) error {
+	if err := r.Check(); err != nil {
+		return err
+	}
+	for _, cell := range cs {
+		ws.validation[cell.ID()] = r
+		cell.Commit(false)
+	}
+	ws.RecalculateAll()
+	return nil
+}
+
+// DeleteValidation removes the validation rule for each of the cells. the
+// cells and the cells that depend on them are recalculated in the same way as
+// for SetValidation()
+func (ws *Worksheet) DeleteValidation(cs ...*cells.Cell) {
+	for _, cell := range cs {
+		delete(ws.validation, cell.ID())
+		cell.Commit(false)
+	}
+	ws.RecalculateAll()
+}
+
+// Validate implements the cells.Worksheet interface. the entry is only
+// rejected if the cell is being committed by CommitEdit(). a value that fails
+// validation at any other time, such as when a cell that it depends on has
+// changed, is given a warning instead
+func (ws Worksheet) Validate(cell *cells.Cell) (bool, error) {
+	r, ok := ws.validation[cell.ID()]
+	if !ok {
+		return false, nil
+	}
+
+	var exact string
+	var err error
+	ws.engine.WithErrorSupression(func() {
+		exact, err = ws.engine.ExactValue(cell.Position().Reference())
+	})
+	if err == nil {
+		err = r.Validate(exact, func(ex string) (bool, error) {
+			return ws.evaluateCondition(cell, ex)
+		})
+	}
+	if err != nil && r.Action == validation.ActionReject && ws.editing[cell.ID()] {
+		return true, fmt.Errorf("%w: %w", validation.Rejected, err)
+	}
+	return false, err
+}
+
+// CommitEdit commits the new entry of a cell that has been edited by the user
+// and recalculates the worksheet. if the entry is rejected by the validation
+// rule for the cell then the previous entry is restored and the Rejected error
+// is returned
+func (ws *Worksheet) CommitEdit(cell *cells.Cell, previous string) error {
+	err := ws.commitEdit(cell, previous)
+	ws.RecalculateAll()
+	return err
+}
+
+// commit the new entry of the cell in the same way as CommitEdit() but without
+// recalculating the worksheet
+func (ws Worksheet) commitEdit(cell *cells.Cell, previous string) error {
+	ws.editing[cell.ID()] = true
+	cell.Commit(true)
+	delete(ws.editing, cell.ID())
+
+	err := cell.Error()
+	if !errors.Is(err, validation.Rejected) {
+		return nil
+	}
+
+	cell.Entry = previous
+	cell.Commit(true)
+	return err
+}
@@ -1,29 +1,84 @@
 package worksheet
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/jetsetilly/ivycel/bitfields"
 	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/dependency"
 	"github.com/jetsetilly/ivycel/engine"
 	"github.com/jetsetilly/ivycel/references"
+	"github.com/jetsetilly/ivycel/validation"
 )
 
+var DuplicateLayout = errors.New("a layout with that name already exists")
+
 type User func(cell *cells.Cell)
 
+// the number of rows and columns in the worksheet
+type size struct {
+	rows    int
+	columns int
+}
+
 type Worksheet struct {
 	engine engine.Interface
 	user   User
 
-	rows    int
-	columns int
+	// the size is shared by every copy of the worksheet, in the same way as
+	// the maps, because cells keep a pointer to the worksheet that created
+	// them
+	size *size
 
 	// current positions of cells references by cell ID
 	positions       map[cells.CellID]cells.Position
 	cellsByPosition map[cells.Position]cells.CellID
 	cellsByID       map[cells.CellID]*cells.Cell
 
+	// bitfield layouts by name. cells refer to layouts by name so that a
+	// layout can be used by more than one cell
+	layouts map[string]bitfields.Layout
+
+	// the seed for the random number generator. the generator is reseeded
+	// whenever volatile cells are recalculated
+	seed int64
+
+	// in manual calculation mode, changes mark the cells that depend on the
+	// change as dirty. the calculated state of each cell is used to decide
+	// what has changed
+	calculation Calculation
+	dirty       map[cells.CellID]bool
+	calculated  map[cells.CellID]string
+
+	// iterative calculation of cells that depend on themselves. the result of
+	// the most recent calculation is kept so that it can be shown to the user
+	iteration       Iteration
+	iterationResult *IterationResult
+
+	// named sets of entries for input cells
+	scenarios []Scenario
+
+	// conditional formatting rules and the format for each cell that matches
+	// a rule. the formats are updated whenever the worksheet is recalculated
+	rules   []Rule
+	formats map[cells.CellID]Format
+
+	// validation rules for input cells and the cells being committed by
+	// CommitEdit(). a rule can only reject the entry of a cell that is being
+	// edited
+	validation map[cells.CellID]validation.Rule
+	editing    map[cells.CellID]bool
+
+	// the names assigned in the worksheet while the worksheet is being
+	// recalculated. nil at other times
+	names *map[string][]cells.Position
+
 	User any
 }
 
@@ -31,19 +86,30 @@ func NewWorksheet(engine engine.Interface, rows int, columns int, user User) Wor
 	ws := Worksheet{
 		engine:          engine,
 		user:            user,
-		rows:            rows,
-		columns:         columns,
+		size:            &size{rows: rows, columns: columns},
 		positions:       make(map[cells.CellID]cells.Position),
 		cellsByPosition: make(map[cells.Position]cells.CellID),
 		cellsByID:       make(map[cells.CellID]*cells.Cell),
+		layouts:         make(map[string]bitfields.Layout),
+		dirty:           make(map[cells.CellID]bool),
+		calculated:      make(map[cells.CellID]string),
+		iteration:       DefaultIteration,
+		iterationResult: &IterationResult{},
+		formats:         make(map[cells.CellID]Format),
+		validation:      make(map[cells.CellID]validation.Rule),
+		editing:         make(map[cells.CellID]bool),
+		names:           new(map[string][]cells.Position),
 	}
 
-	for row := range ws.rows {
-		for col := range ws.columns {
+	for row := range ws.size.rows {
+		for col := range ws.size.columns {
 			ws.createCell(cells.Position{Row: row, Column: col})
 		}
 	}
 
+	_ = ws.engine.SetSeed(ws.seed)
+	ws.updateCalculated()
+
 	return ws
 }
 
@@ -74,8 +140,8 @@ func (ws *Worksheet) adjustCells(adj func(p cells.Position) cells.Adjustment) {
 	}
 
 	// change expressions for all cells
-	for rowi := range ws.rows {
-		for coli := range ws.columns {
+	for rowi := range ws.size.rows {
+		for coli := range ws.size.columns {
 			pos := cells.Position{Row: rowi, Column: coli}
 			id := ws.cellsByPosition[pos]
 			cell := ws.cellsByID[id]
@@ -89,13 +155,18 @@ func (ws *Worksheet) adjustCells(adj func(p cells.Position) cells.Adjustment) {
 			cell.Commit(false)
 		}
 	}
+
+	// conditional formatting rules follow the cells they apply to
+	for i, r := range ws.rules {
+		ws.rules[i].Range = cells.NewRange(r.Range.Start.Adjust(adj(r.Range.Start)), r.Range.End.Adjust(adj(r.Range.End)))
+	}
 }
 
 func (ws *Worksheet) InsertRow(at int) {
 	defer ws.RecalculateAll()
 
-	for rowi := ws.rows; rowi >= at; rowi-- {
-		for coli := range ws.columns {
+	for rowi := ws.size.rows; rowi >= at; rowi-- {
+		for coli := range ws.size.columns {
 			pos := cells.Position{Row: rowi, Column: coli}
 			id := ws.cellsByPosition[pos]
 			pos.Row++
@@ -103,11 +174,11 @@ func (ws *Worksheet) InsertRow(at int) {
 			ws.positions[id] = pos
 		}
 	}
-	for coli := range ws.columns {
+	for coli := range ws.size.columns {
 		ws.createCell(cells.Position{Row: at, Column: coli})
 	}
 
-	ws.rows++
+	ws.size.rows++
 
 	ws.adjustCells(func(p cells.Position) cells.Adjustment {
 		if p.Row >= at {
@@ -120,8 +191,8 @@ func (ws *Worksheet) InsertRow(at int) {
 func (ws *Worksheet) InsertColumn(at int) {
 	defer ws.RecalculateAll()
 
-	for coli := ws.columns; coli >= at; coli-- {
-		for rowi := range ws.rows {
+	for coli := ws.size.columns; coli >= at; coli-- {
+		for rowi := range ws.size.rows {
 			pos := cells.Position{Row: rowi, Column: coli}
 			id := ws.cellsByPosition[pos]
 			pos.Column++
@@ -129,11 +200,11 @@ func (ws *Worksheet) InsertColumn(at int) {
 			ws.positions[id] = pos
 		}
 	}
-	for rowi := range ws.rows {
+	for rowi := range ws.size.rows {
 		ws.createCell(cells.Position{Row: rowi, Column: at})
 	}
 
-	ws.columns++
+	ws.size.columns++
 
 	ws.adjustCells(func(p cells.Position) cells.Adjustment {
 		if p.Column >= at {
@@ -143,32 +214,462 @@ func (ws *Worksheet) InsertColumn(at int) {
 	})
 }
 
+// Grow the worksheet so that it is at least the size specified. new rows and
+// columns are added to the end of the worksheet and so no cell references
+// need adjusting
+func (ws *Worksheet) Grow(rows int, columns int) {
+	for ; ws.size.columns < columns; ws.size.columns++ {
+		for rowi := range ws.size.rows {
+			ws.createCell(cells.Position{Row: rowi, Column: ws.size.columns})
+		}
+	}
+	for ; ws.size.rows < rows; ws.size.rows++ {
+		for coli := range ws.size.columns {
+			ws.createCell(cells.Position{Row: ws.size.rows, Column: coli})
+		}
+	}
+}
+
 func (ws Worksheet) Cell(row int, column int) *cells.Cell {
 	id := ws.cellsByPosition[cells.Position{Row: row, Column: column}]
 	return ws.cellsByID[id]
 }
 
+// Generation returns a number that changes whenever any cell in the worksheet
+// is committed
+func (ws Worksheet) Generation() int {
+	var g int
+	for _, c := range ws.cellsByID {
+		g += c.Generation()
+	}
+	return g
+}
+
 func (ws Worksheet) Size() (int, int) {
-	return ws.rows, ws.columns
+	return ws.size.rows, ws.size.columns
+}
+
+// DefaultBase returns the number base used by cells that have not had their
+// base changed
+func (ws Worksheet) DefaultBase() engine.Base {
+	return ws.engine.Base()
+}
+
+// SetDefaultBase changes the number base used by cells that have not had their
+// base changed. those cells are re-evaluated along with all other cells in the
+// worksheet because any cell might refer to an affected cell
+func (ws *Worksheet) SetDefaultBase(base engine.Base) error {
+	err := ws.engine.SetBase(base)
+	if err != nil {
+		return err
+	}
+	ws.recalculateWorksheet()
+	return nil
+}
+
+// DefaultSettings returns the engine settings used by cells that have not had
+// their settings changed
+func (ws Worksheet) DefaultSettings() engine.Settings {
+	return ws.engine.Settings()
+}
+
+// SetDefaultSettings changes the engine settings used by cells that have not
+// had their settings changed. all cells are re-evaluated in the same way as
+// for SetDefaultBase()
+func (ws *Worksheet) SetDefaultSettings(settings engine.Settings) error {
+	err := ws.engine.SetSettings(settings)
+	if err != nil {
+		return err
+	}
+	ws.recalculateWorksheet()
+	return nil
 }
 
+// RecalculateAll commits every cell in the worksheet except for volatile
+// cells. cells are committed after the cells they depend on, including cells
+// that assign to a name used by the cell. otherwise cells are committed in row
+// order
+//
+// in manual calculation mode no cell is committed. instead, the cells that
+// depend on a cell that has changed are marked as dirty
 func (ws Worksheet) RecalculateAll() {
-	ws.engine.WithErrorSupression(func() {
-		for rowi := range ws.rows {
-			for coli := range ws.columns {
-				id := ws.cellsByPosition[cells.Position{Row: rowi, Column: coli}]
-				ws.cellsByID[id].Commit(false)
+	defer ws.updateFormats()
+
+	if ws.calculation == CalculationManual {
+		ws.markDirty()
+		return
+	}
+
+	include := func(cell *cells.Cell) bool {
+		return !ws.Volatile(cell)
+	}
+
+	order, cycles, names := ws.calculationOrder()
+	ws.withNames(names, func() {
+		ws.engine.WithErrorSupression(func() {
+			ws.commitInOrder(order, cycles, include)
+		})
+	})
+	ws.updateCalculated()
+}
+
+// recalculate the worksheet after a change to the worksheet rather than to a
+// cell. in manual calculation mode there is no changed cell to start from so
+// every cell with an entry is marked as dirty
+func (ws Worksheet) recalculateWorksheet() {
+	if ws.calculation == CalculationManual {
+		for id, cell := range ws.cellsByID {
+			if cell.Entry != "" {
+				ws.dirty[id] = true
 			}
 		}
+		ws.updateFormats()
+		return
+	}
+	ws.RecalculateAll()
+}
+
+// RecalculateVolatile reseeds the random number generator and commits every
+// volatile cell before recalculating the rest of the worksheet. the volatile
+// cells are always committed in the same order so the results will be the
+// same for the same seed
+func (ws Worksheet) RecalculateVolatile() {
+	_ = ws.engine.SetSeed(ws.seed)
+	order, _, names := ws.calculationOrder()
+	ws.withNames(names, func() {
+		ws.engine.WithErrorSupression(func() {
+			for _, p := range order {
+				cell := ws.cellsByID[ws.cellsByPosition[p]]
+				if ws.Volatile(cell) {
+					cell.Commit(false)
+				}
+			}
+		})
 	})
+	ws.RecalculateAll()
+}
+
+// the position of every cell in the order that they should be committed, the
+// groups of cells that depend on each other and the cells that assign to each
+// name
+func (ws Worksheet) calculationOrder() ([]cells.Position, [][]cells.Position, map[string][]cells.Position) {
+	var ps []cells.Position
+	for rowi := range ws.size.rows {
+		for coli := range ws.size.columns {
+			ps = append(ps, cells.Position{Row: rowi, Column: coli})
+		}
+	}
+	g, names := ws.dependencies()
+	ps = g.Order(ps)
+	return ps, g.Cycles(ps), names
+}
+
+// Volatile returns true if the result of the cell changes every time it is
+// committed. volatile cells are those that use random numbers
+func (ws Worksheet) Volatile(cell *cells.Cell) bool {
+	if cell.Parent() != nil || !strings.Contains(cell.Entry, "?") {
+		return false
+	}
+	return engine.UsesRandom(cell.Entry, ws.engine.Tokens(cell.Entry))
+}
+
+// Seed returns the seed for the random number generator
+func (ws Worksheet) Seed() int64 {
+	return ws.seed
+}
+
+// SetSeed changes the seed for the random number generator and recalculates
+// the volatile cells with the new seed
+func (ws *Worksheet) SetSeed(seed int64) error {
+	err := ws.engine.SetSeed(seed)
+	if err != nil {
+		return err
+	}
+	ws.seed = seed
+	ws.RecalculateVolatile()
+	return nil
 }
 
 func (ws Worksheet) RelativeCell(root *cells.Cell, pos cells.Position) *cells.Cell {
 	pos.Row += root.Position().Row
 	pos.Column += root.Position().Column
-	if pos.Row >= ws.rows || pos.Column >= ws.columns {
+	if pos.Row >= ws.size.rows || pos.Column >= ws.size.columns {
 		return nil
 	}
 	id := ws.cellsByPosition[pos]
 	return ws.cellsByID[id]
 }
+
+// Layout returns the named bitfield layout
+func (ws Worksheet) Layout(name string) (bitfields.Layout, bool) {
+	l, ok := ws.layouts[name]
+	return l, ok
+}
+
+// Layouts returns the names of all bitfield layouts in alphabetical order
+func (ws Worksheet) Layouts() []string {
+	var names []string
+	for n := range ws.layouts {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SetLayout adds or replaces a named bitfield layout. any cell can then use the
+// layout by name
+func (ws *Worksheet) SetLayout(name string, layout bitfields.Layout) {
+	defer ws.recalculateWorksheet()
+	ws.layouts[name] = layout
+}
+
+// DeleteLayout removes the named bitfield layout. cells that use the layout
+// will no longer have a layout
+func (ws *Worksheet) DeleteLayout(name string) {
+	defer ws.recalculateWorksheet()
+	delete(ws.layouts, name)
+	for _, c := range ws.cellsByID {
+		if c.Layout() == name {
+			c.SetLayout("")
+		}
+	}
+}
+
+// RenameLayout changes the name of a bitfield layout. cells that use the
+// layout are changed to use the new name. returns a DuplicateLayout error if
+// there is already a layout with the new name
+func (ws *Worksheet) RenameLayout(from string, to string) error {
+	l, ok := ws.layouts[from]
+	if !ok {
+		return nil
+	}
+	if _, ok := ws.layouts[to]; ok {
+		return fmt.Errorf("%w: %s", DuplicateLayout, to)
+	}
+	defer ws.recalculateWorksheet()
+	delete(ws.layouts, from)
+	ws.layouts[to] = l
+	for _, c := range ws.cellsByID {
+		if c.Layout() == from {
+			c.SetLayout(to)
+		}
+	}
+	return nil
+}
+
+// ExpandReferences implements the cells.Worksheet interface
+func (ws Worksheet) ExpandReferences(ex string, inputBase int) (string, error) {
+	ex, err := ws.expandRangeReferences(ex, inputBase)
+	if err != nil {
+		return ex, err
+	}
+	return ws.expandFieldReferences(ex, inputBase)
+}
+
+// a range reference is expanded to a vector of cell references. if the range
+// has more than one row and more than one column then the vector is reshaped
+// into a matrix
+func (ws Worksheet) expandRangeReferences(ex string, inputBase int) (string, error) {
+	return references.ExpandRangeReferences(ex, func(start string, end string) (string, error) {
+		rng, err := cells.RangeFromReference(fmt.Sprintf("%s:%s", start, end))
+		if err != nil {
+			return "", err
+		}
+		if rng.End.Row >= ws.size.rows || rng.End.Column >= ws.size.columns {
+			return "", fmt.Errorf("{%s} is outside the worksheet", rng.Reference())
+		}
+
+		var refs []string
+		for row := rng.Start.Row; row <= rng.End.Row; row++ {
+			for col := rng.Start.Column; col <= rng.End.Column; col++ {
+				p := cells.Position{Row: row, Column: col}
+				refs = append(refs, references.WrapCellReference(p.Reference()))
+			}
+		}
+
+		if rng.Rows() == 1 || rng.Columns() == 1 {
+			return fmt.Sprintf("(%s)", strings.Join(refs, " ")), nil
+		}
+
+		return fmt.Sprintf("(%s %s rho %s)",
+			engine.FormatInteger(strconv.FormatInt(int64(rng.Rows()), inputBase)),
+			engine.FormatInteger(strconv.FormatInt(int64(rng.Columns()), inputBase)),
+			strings.Join(refs, " ")), nil
+	})
+}
+
+func (ws Worksheet) expandFieldReferences(ex string, inputBase int) (string, error) {
+	return references.ExpandFieldReferences(ex, func(ref string, field string) (string, error) {
+		p, err := cells.PositionFromReference(ref)
+		if err != nil {
+			return "", err
+		}
+		if p.Row >= ws.size.rows || p.Column >= ws.size.columns {
+			return "", fmt.Errorf("%s is outside the worksheet", references.WrapCellReference(ref))
+		}
+
+		cell := ws.Cell(p.Row, p.Column)
+		layout, ok := ws.layouts[cell.Layout()]
+		if !ok {
+			return "", fmt.Errorf("%s has no bitfield layout", references.WrapCellReference(ref))
+		}
+
+		f, ok := layout.Field(field)
+		if !ok {
+			return "", fmt.Errorf("%s has no field named %s", references.WrapCellReference(ref), field)
+		}
+
+		return f.Expression(references.WrapCellReference(p.Reference()), inputBase), nil
+	})
+}
+
+// Dependencies returns the graph of references between the cells in the
+// worksheet. a child cell depends on its parent and a cell that uses a name
+// depends on every other cell that assigns to the name
+func (ws Worksheet) Dependencies() *dependency.Graph {
+	g, _ := ws.dependencies()
+	return g
+}
+
+// the dependency graph and the cells that assign to each name. the entry of
+// each cell is only tokenised once
+func (ws Worksheet) dependencies() (*dependency.Graph, map[string][]cells.Position) {
+	usage := ws.allNameUsage()
+	assigned := ws.assignedNames(usage)
+
+	g := dependency.NewGraph()
+	for p, id := range ws.cellsByPosition {
+		cell := ws.cellsByID[id]
+		if parent := cell.Parent(); parent != nil {
+			g.AddEdge(p, parent.Position())
+		} else if cell.Entry != "" {
+			g.AddEntry(p, cell.Entry)
+			for _, n := range usage[p].used {
+				for _, q := range assigned[n] {
+					if q != p {
+						g.AddNameEdge(p, q, n)
+					}
+				}
+			}
+		}
+	}
+	return g, assigned
+}
+
+// the names assigned to and used by the entry of a cell
+type nameUsage struct {
+	assigned []string
+	used     []string
+}
+
+func (ws Worksheet) entryNameUsage(entry string) nameUsage {
+	assigned, used := engine.NameUsage(entry, ws.engine.Tokens(entry))
+	return nameUsage{assigned: assigned, used: used}
+}
+
+// the name usage of every cell that has an entry and is not a child cell
+func (ws Worksheet) allNameUsage() map[cells.Position]nameUsage {
+	usage := make(map[cells.Position]nameUsage)
+	for p, id := range ws.cellsByPosition {
+		cell := ws.cellsByID[id]
+		if cell.Parent() != nil || cell.Entry == "" {
+			continue // for loop
+		}
+		usage[p] = ws.entryNameUsage(cell.Entry)
+	}
+	return usage
+}
+
+// the cells that assign to each name in row order
+func (ws Worksheet) assignedNames(usage map[cells.Position]nameUsage) map[string][]cells.Position {
+	names := make(map[string][]cells.Position)
+	for rowi := range ws.size.rows {
+		for coli := range ws.size.columns {
+			p := cells.Position{Row: rowi, Column: coli}
+			for _, n := range usage[p].assigned {
+				names[n] = append(names[n], p)
+			}
+		}
+	}
+	return names
+}
+
+// Names returns the cells that assign to each name in the worksheet. a name
+// is a variable in the engine that is not a cell. the positions for each name
+// are in row order
+func (ws Worksheet) Names() map[string][]cells.Position {
+	return ws.assignedNames(ws.allNameUsage())
+}
+
+// run the function with the names assigned in the worksheet cached for use by
+// NameConflict(). the entries of the cells must not change while the function
+// is running
+func (ws Worksheet) withNames(names map[string][]cells.Position, with func()) {
+	*ws.names = names
+	defer func() {
+		*ws.names = nil
+	}()
+	with()
+}
+
+// NameConflict implements the cells.Worksheet interface
+func (ws Worksheet) NameConflict(ex string, pos cells.Position) error {
+	assigned := ws.entryNameUsage(ex).assigned
+	if len(assigned) == 0 {
+		return nil
+	}
+
+	names := *ws.names
+	if names == nil {
+		names = ws.Names()
+	}
+	for _, n := range assigned {
+		for _, p := range names[n] {
+			if p != pos {
+				return fmt.Errorf("%w: %s is also assigned in %s", cells.DuplicateName,
+					n, references.WrapCellReference(p.Reference()))
+			}
+		}
+	}
+	return nil
+}
+
+// ReferencedError implements the cells.Worksheet interface. the origin of the
+// returned error is the cell where the error first happened, which may not be
+// a cell referred to directly by the expression
+func (ws Worksheet) ReferencedError(ex string) error {
+	for _, loc := range references.FindReferences(ex) {
+		for row := loc.Range.Start.Row; row <= min(loc.Range.End.Row, ws.size.rows-1); row++ {
+			for col := loc.Range.Start.Column; col <= min(loc.Range.End.Column, ws.size.columns-1); col++ {
+				cell := ws.Cell(row, col)
+				// only errors from evaluation are passed on. a cell with an
+				// error in how its result is presented still has a value
+				err := cell.EvalError()
+				if err == nil {
+					continue // for loop
+				}
+
+				// the error in a referenced cell might itself be a dependency
+				// error. the error is only passed on if the origin is still in
+				// error. this prevents cells that refer to each other from
+				// keeping each other in error after the origin has been fixed
+				var dep cells.DependencyError
+				if errors.As(err, &dep) {
+					origin := ws.Cell(dep.Origin.Row, dep.Origin.Column)
+					if origin.EvalError() == nil || errors.As(origin.EvalError(), &cells.DependencyError{}) {
+						continue // for loop
+					}
+					return dep
+				}
+
+				// the error of a child cell is the error of its parent
+				origin := cell
+				if cell.Parent() != nil {
+					origin = cell.Parent()
+				}
+				return cells.DependencyError{Origin: origin.Position(), Err: err}
+			}
+		}
+	}
+	return nil
+}
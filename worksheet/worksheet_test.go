@@ -0,0 +1,477 @@
+package worksheet_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/jetsetilly/ivycel/bitfields"
+	"github.com/jetsetilly/ivycel/cells"
+	"github.com/jetsetilly/ivycel/engine"
+	"github.com/jetsetilly/ivycel/engine/ivy"
+	"github.com/jetsetilly/ivycel/validation"
+	"github.com/jetsetilly/ivycel/worksheet"
+)
+
+func ExpectEquality[T comparable](t *testing.T, value T, expectedValue T) {
+	t.Helper()
+	if value != expectedValue {
+		t.Errorf("equality test of type %T failed: '%v' does not equal '%v')", value, value, expectedValue)
+	}
+}
+
+func ExpectedError(t *testing.T, err error, expected error) {
+	t.Helper()
+	if !errors.Is(err, expected) {
+		t.Errorf("%v is an unexpected error", err)
+	}
+}
+
+func newWorksheet() worksheet.Worksheet {
+	eng := ivy.New()
+	return worksheet.NewWorksheet(&eng, 10, 10, func(*cells.Cell) {})
+}
+
+func cell(t *testing.T, ws worksheet.Worksheet, ref string) *cells.Cell {
+	t.Helper()
+	p, err := cells.PositionFromReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws.Cell(p.Row, p.Column)
+}
+
+// change the entry of the cell in the same way as the user
+func edit(t *testing.T, ws worksheet.Worksheet, ref string, entry string) error {
+	t.Helper()
+	c := cell(t, ws, ref)
+	previous := c.Entry
+	c.Entry = entry
+	return ws.CommitEdit(c, previous)
+}
+
+func TestRecalculate(t *testing.T) {
+	ws := newWorksheet()
+	edit(t, ws, "A1", "2")
+	edit(t, ws, "B1", "{A1} * 3")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "6")
+
+	edit(t, ws, "A1", "4")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "12")
+}
+
+func TestManualCalculation(t *testing.T) {
+	ws := newWorksheet()
+	edit(t, ws, "A1", "2")
+	edit(t, ws, "B1", "{A1} + 1")
+	ws.SetCalculation(worksheet.CalculationManual)
+
+	edit(t, ws, "A1", "5")
+	ExpectEquality(t, cell(t, ws, "A1").Result(), "5")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "3")
+	ExpectEquality(t, ws.Dirty(cell(t, ws, "B1")), true)
+
+	ws.Calculate()
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "6")
+	ExpectEquality(t, ws.DirtyCount(), 0)
+
+	// a change to the worksheet rather than to a cell marks every cell with
+	// an entry as dirty
+	err := ws.SetDefaultSettings(engine.Settings{Format: "%.2f"})
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, ws.DirtyCount(), 2)
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "6")
+
+	ws.Calculate()
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "6.00")
+	ExpectEquality(t, ws.DirtyCount(), 0)
+}
+
+func TestDefaultBase(t *testing.T) {
+	ws := newWorksheet()
+	edit(t, ws, "A1", "10")
+	edit(t, ws, "B1", "10")
+	ExpectEquality(t, cell(t, ws, "B1").SetBase(engine.Base{Input: 10}), nil)
+
+	// cells that haven't overridden the input base read their entries again in
+	// the new default base
+	ExpectEquality(t, ws.SetDefaultBase(engine.Base{Input: 16, Output: 10}), nil)
+	ExpectEquality(t, cell(t, ws, "A1").Result(), "16")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "10")
+
+	ExpectedError(t, ws.SetDefaultBase(engine.Base{Input: 0, Output: 10}), engine.InvalidBase)
+	ExpectedError(t, ws.SetDefaultBase(engine.Base{Input: 16, Output: 37}), engine.InvalidBase)
+	ExpectEquality(t, ws.DefaultBase(), engine.Base{Input: 16, Output: 10})
+	ExpectEquality(t, cell(t, ws, "A1").Result(), "16")
+
+	// a cell base is checked after it has been resolved with the default base
+	ExpectedError(t, cell(t, ws, "A1").SetBase(engine.Base{Output: 1}), engine.InvalidBase)
+}
+
+func TestGrow(t *testing.T) {
+	ws := newWorksheet()
+	ws.Grow(10, 12)
+	rows, columns := ws.Size()
+	ExpectEquality(t, rows, 10)
+	ExpectEquality(t, columns, 12)
+
+	// cells created before the worksheet grew can spill into the new columns
+	edit(t, ws, "J1", "1 2 3")
+	ExpectEquality(t, cell(t, ws, "L1").Result(), "3")
+	ExpectEquality(t, cell(t, ws, "J1").Warning(), nil)
+
+	// and refer to cells in the new columns
+	edit(t, ws, "K2", "5")
+	edit(t, ws, "A2", "+/ {J2:K2}")
+	ExpectEquality(t, cell(t, ws, "A2").Error(), nil)
+	ExpectEquality(t, cell(t, ws, "A2").Result(), "5")
+}
+
+func TestIteration(t *testing.T) {
+	ws := newWorksheet()
+	edit(t, ws, "A1", "{B1} + 1")
+	edit(t, ws, "B1", "{A1}")
+	ExpectedError(t, cell(t, ws, "A1").Warning(), cells.CircularReference)
+	ExpectEquality(t, ws.IterationResult().Iterated, false)
+
+	// the warning is added once however many times the worksheet is
+	// recalculated
+	ws.RecalculateAll()
+	ws.RecalculateAll()
+	for _, ref := range []string{"A1", "B1"} {
+		w := cell(t, ws, ref).Warning()
+		ExpectedError(t, w, cells.CircularReference)
+		if w != nil {
+			ExpectEquality(t, strings.Count(w.Error(), cells.CircularReference.Error()), 1)
+		}
+	}
+
+	err := ws.SetIteration(worksheet.Iteration{Enabled: true, MaxIterations: 0})
+	ExpectedError(t, err, worksheet.InvalidIteration)
+	err = ws.SetIteration(worksheet.Iteration{Enabled: true, MaxIterations: 100, Tolerance: -1})
+	ExpectedError(t, err, worksheet.InvalidIteration)
+
+	err = ws.SetIteration(worksheet.Iteration{Enabled: true, MaxIterations: 100, Tolerance: 0.001})
+	ExpectEquality(t, err, nil)
+
+	edit(t, ws, "A1", "({B1} / 2) + 1")
+	edit(t, ws, "D1", "{A1}")
+	ws.RecalculateAll()
+	ExpectEquality(t, ws.IterationResult().Iterated, true)
+	ExpectEquality(t, ws.IterationResult().Converged, true)
+	ExpectEquality(t, cell(t, ws, "A1").Warning(), nil)
+
+	// a cell that depends on a cycle is committed after the cycle
+	ExpectEquality(t, cell(t, ws, "D1").Result(), cell(t, ws, "A1").Result())
+
+	// a cycle that doesn't converge within the maximum number of iterations
+	err = ws.SetIteration(worksheet.Iteration{Enabled: true, MaxIterations: 5, Tolerance: 0})
+	ExpectEquality(t, err, nil)
+	edit(t, ws, "A1", "{B1} + 1")
+	ExpectEquality(t, ws.IterationResult().Converged, false)
+	ExpectEquality(t, ws.IterationResult().Iterations, 5)
+	ExpectedError(t, cell(t, ws, "A1").Warning(), cells.NotConverged)
+}
+
+func TestWhatIf(t *testing.T) {
+	ws := newWorksheet()
+	edit(t, ws, "A1", "2")
+	edit(t, ws, "A2", "3")
+	edit(t, ws, "B1", "{A1} * 10")
+	edit(t, ws, "C1", "{B1} + {A2}")
+
+	// the formula and the cells between the input and the formula are
+	// calculated with the new value but the cells themselves don't change
+	r, err := ws.WhatIf(cell(t, ws, "C1"), []*cells.Cell{cell(t, ws, "A1")}, []string{"3"})
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, r, "33")
+	ExpectEquality(t, cell(t, ws, "A1").Result(), "2")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "20")
+	ExpectEquality(t, cell(t, ws, "C1").Result(), "23")
+
+	_, err = ws.WhatIf(cell(t, ws, "C1"), []*cells.Cell{cell(t, ws, "A1")}, nil)
+	ExpectEquality(t, err != nil, true)
+	_, err = ws.WhatIf(cell(t, ws, "A2"), []*cells.Cell{cell(t, ws, "A1")}, []string{"3"})
+	ExpectEquality(t, err != nil, true)
+
+	table := ws.DataTable(cell(t, ws, "B1"), cell(t, ws, "A1"), []string{"1", "5"}, nil, nil)
+	ExpectEquality(t, len(table), 2)
+	ExpectEquality(t, table[0][0], "10")
+	ExpectEquality(t, table[1][0], "50")
+
+	table = ws.DataTable(cell(t, ws, "C1"), cell(t, ws, "A1"), []string{"1", "5"}, cell(t, ws, "A2"), []string{"0", "1", "2"})
+	ExpectEquality(t, len(table), 2)
+	ExpectEquality(t, len(table[1]), 3)
+	ExpectEquality(t, table[0][0], "10")
+	ExpectEquality(t, table[1][2], "52")
+
+	// an input that is part of the result of another cell can't be changed
+	edit(t, ws, "A3", "1 2 3")
+	child := cell(t, ws, "B3")
+	ExpectEquality(t, child.ReadOnly(), true)
+	_, err = ws.WhatIf(cell(t, ws, "B1"), []*cells.Cell{child}, []string{"3"})
+	ExpectEquality(t, err != nil, true)
+	ExpectEquality(t, child.Result(), "2")
+
+	// a formula that depends on the input through a spilled result
+	edit(t, ws, "A5", "{A1} {A1}")
+	edit(t, ws, "A6", "{B5} + 1")
+	_, err = ws.WhatIf(cell(t, ws, "A6"), []*cells.Cell{cell(t, ws, "A1")}, []string{"3"})
+	ExpectedError(t, err, worksheet.SpilledDependency)
+
+	// or through a volatile cell
+	edit(t, ws, "A7", "{A1} + ?10")
+	edit(t, ws, "A8", "{A7} + 1")
+	_, err = ws.WhatIf(cell(t, ws, "A8"), []*cells.Cell{cell(t, ws, "A1")}, []string{"3"})
+	ExpectedError(t, err, worksheet.VolatileDependency)
+}
+
+func TestScenarios(t *testing.T) {
+	ws := newWorksheet()
+	edit(t, ws, "A1", "1")
+	edit(t, ws, "B1", "{A1} + 1")
+	edit(t, ws, "C1", "{A1} + ?1000000")
+
+	ExpectEquality(t, ws.SetScenario("one", []*cells.Cell{cell(t, ws, "A1")}), nil)
+	edit(t, ws, "A1", "5")
+	ExpectEquality(t, ws.SetScenario("five", []*cells.Cell{cell(t, ws, "A1")}), nil)
+	edit(t, ws, "A1", "7")
+
+	volatile := cell(t, ws, "C1").Result()
+	summary := ws.ScenarioSummary([]*cells.Cell{cell(t, ws, "B1")})
+	ExpectEquality(t, len(summary), 1)
+	ExpectEquality(t, summary[0][0], "2")
+	ExpectEquality(t, summary[0][1], "6")
+
+	// the worksheet is returned to its state before the summary and the
+	// volatile cell is not committed
+	ExpectEquality(t, cell(t, ws, "A1").Result(), "7")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "8")
+	ExpectEquality(t, cell(t, ws, "C1").Result(), volatile)
+
+	// dirty cells are still dirty after the summary
+	ws.SetCalculation(worksheet.CalculationManual)
+	edit(t, ws, "A1", "9")
+	ExpectEquality(t, ws.Dirty(cell(t, ws, "B1")), true)
+	dirty := ws.DirtyCount()
+	summary = ws.ScenarioSummary([]*cells.Cell{cell(t, ws, "B1")})
+	ExpectEquality(t, summary[0][1], "6")
+	ExpectEquality(t, ws.Dirty(cell(t, ws, "B1")), true)
+	ExpectEquality(t, ws.DirtyCount(), dirty)
+	ExpectEquality(t, cell(t, ws, "A1").Result(), "9")
+	ws.SetCalculation(worksheet.CalculationAutomatic)
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "10")
+
+	prev, err := ws.ApplyScenario("one")
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, prev[cell(t, ws, "A1")], "9")
+	ExpectEquality(t, cell(t, ws, "B1").Result(), "2")
+
+	_, err = ws.ApplyScenario("two")
+	ExpectedError(t, err, worksheet.NoScenario)
+
+	// a cell that has become part of the result of another cell is not changed
+	ExpectEquality(t, ws.SetScenario("spill", []*cells.Cell{cell(t, ws, "E2")}), nil)
+	edit(t, ws, "D2", "1 2")
+	prev, err = ws.ApplyScenario("spill")
+	ExpectEquality(t, err != nil, true)
+	ExpectEquality(t, len(prev), 0)
+	ExpectEquality(t, cell(t, ws, "E2").ReadOnly(), true)
+	ExpectEquality(t, cell(t, ws, "E2").Result(), "2")
+
+	// entries are validated in the same way as an edit
+	edit(t, ws, "A1", "1/2")
+	ExpectEquality(t, ws.SetScenario("half", []*cells.Cell{cell(t, ws, "A1")}), nil)
+	edit(t, ws, "A1", "1")
+	rule := validation.Rule{Kind: validation.KindInteger, Action: validation.ActionReject}
+	ExpectEquality(t, ws.SetValidation(rule, cell(t, ws, "A1")), nil)
+	prev, err = ws.ApplyScenario("half")
+	ExpectedError(t, err, validation.Rejected)
+	ExpectEquality(t, len(prev), 0)
+	ExpectEquality(t, cell(t, ws, "A1").Entry, "1")
+}
+
+func TestConditionalFormat(t *testing.T) {
+	ws := newWorksheet()
+	ExpectEquality(t, ws.SetDefaultSettings(engine.Settings{Format: "%.2f"}), nil)
+
+	edit(t, ws, "A1", "3")
+	edit(t, ws, "B1", "12")
+	edit(t, ws, "A2", "{B1}")
+	ExpectEquality(t, cell(t, ws, "A2").SetBase(engine.Base{Input: 16}), nil)
+
+	rng, err := cells.RangeFromReference("A1:A2")
+	ExpectEquality(t, err, nil)
+	err = ws.AddRule(worksheet.Rule{Range: rng, Condition: " "})
+	ExpectEquality(t, err != nil, true)
+	err = ws.AddRule(worksheet.Rule{Range: rng, Condition: "{.} > 10", Format: worksheet.Format{Icon: "!"}})
+	ExpectEquality(t, err, nil)
+
+	// a false condition is not matched even though the format prints zero as
+	// 0.00
+	_, ok := ws.ConditionalFormat(cell(t, ws, "A1"))
+	ExpectEquality(t, ok, false)
+
+	// the number in the rule is decimal even though the input base of the cell
+	// is hexadecimal
+	f, ok := ws.ConditionalFormat(cell(t, ws, "A2"))
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, f.Icon, "!")
+
+	// a rule with a lower priority only sets the parts of the format that
+	// haven't been set by a rule with a higher priority
+	red := color.RGBA{R: 255, A: 255}
+	err = ws.AddRule(worksheet.Rule{Range: rng, Condition: "{.} > 0", Format: worksheet.Format{Icon: "?", Background: red}})
+	ExpectEquality(t, err, nil)
+	f, _ = ws.ConditionalFormat(cell(t, ws, "A1"))
+	ExpectEquality(t, f, worksheet.Format{Icon: "?", Background: red})
+	f, _ = ws.ConditionalFormat(cell(t, ws, "A2"))
+	ExpectEquality(t, f, worksheet.Format{Icon: "!", Background: red})
+
+	// formats follow changes to the cells
+	edit(t, ws, "B1", "2")
+	f, _ = ws.ConditionalFormat(cell(t, ws, "A2"))
+	ExpectEquality(t, f, worksheet.Format{Icon: "?", Background: red})
+
+	ws.DeleteRule(1)
+	ExpectEquality(t, len(ws.Rules()), 1)
+	_, ok = ws.ConditionalFormat(cell(t, ws, "A1"))
+	ExpectEquality(t, ok, false)
+}
+
+func TestValidation(t *testing.T) {
+	ws := newWorksheet()
+	a1 := cell(t, ws, "A1")
+
+	err := ws.SetValidation(validation.Rule{Kind: validation.KindRange, Action: validation.ActionReject}, a1)
+	ExpectedError(t, err, validation.InvalidRule)
+	_, ok := ws.Validation(a1)
+	ExpectEquality(t, ok, false)
+
+	err = ws.SetValidation(validation.Rule{Kind: validation.KindRange, Action: validation.ActionReject, Min: "0", Max: "10"}, a1)
+	ExpectEquality(t, err, nil)
+
+	ExpectEquality(t, edit(t, ws, "A1", "5"), nil)
+	ExpectEquality(t, a1.Result(), "5")
+
+	// a rejected entry is replaced by the entry from before the edit
+	ExpectedError(t, edit(t, ws, "A1", "20"), validation.Rejected)
+	ExpectEquality(t, a1.Entry, "5")
+	ExpectEquality(t, a1.Result(), "5")
+
+	// a value that fails validation because another cell has changed is given
+	// a warning rather than rejected
+	edit(t, ws, "B1", "5")
+	ExpectEquality(t, edit(t, ws, "A1", "{B1}"), nil)
+	edit(t, ws, "B1", "20")
+	ExpectEquality(t, a1.Entry, "{B1}")
+	ExpectEquality(t, a1.Error(), nil)
+	ExpectedError(t, a1.Warning(), validation.Invalid)
+
+	// a custom rule is false for a formatted zero
+	ExpectEquality(t, ws.SetDefaultSettings(engine.Settings{Format: "%.2f"}), nil)
+	c1 := cell(t, ws, "C1")
+	err = ws.SetValidation(validation.Rule{Kind: validation.KindCustom, Action: validation.ActionReject, Expression: "{.} > 2"}, c1)
+	ExpectEquality(t, err, nil)
+	ExpectedError(t, edit(t, ws, "C1", "1"), validation.Rejected)
+	ExpectEquality(t, c1.Entry, "")
+
+	// a rule can be set for several cells at once. the existing values of the
+	// cells are given a warning if they fail the rule
+	edit(t, ws, "D1", "1/2")
+	edit(t, ws, "D2", "3")
+	d1 := cell(t, ws, "D1")
+	d2 := cell(t, ws, "D2")
+	err = ws.SetValidation(validation.Rule{Kind: validation.KindInteger, Action: validation.ActionReject}, d1, d2)
+	ExpectEquality(t, err, nil)
+	ExpectedError(t, d1.Warning(), validation.Invalid)
+	ExpectEquality(t, d2.Warning(), nil)
+	_, ok = ws.Validation(d2)
+	ExpectEquality(t, ok, true)
+
+	ws.DeleteValidation(d1, d2)
+	ExpectEquality(t, d1.Warning(), nil)
+	_, ok = ws.Validation(d1)
+	ExpectEquality(t, ok, false)
+	_, ok = ws.Validation(d2)
+	ExpectEquality(t, ok, false)
+}
+
+func TestSaveLoad(t *testing.T) {
+	ws := newWorksheet()
+	ExpectEquality(t, ws.SetDefaultSettings(engine.Settings{MaxDigits: 100}), nil)
+	edit(t, ws, "A1", "255")
+	edit(t, ws, "B1", "{A1} + 1")
+	edit(t, ws, "D1", "?1000000")
+	ExpectEquality(t, cell(t, ws, "A1").SetBase(engine.Base{Output: 16}), nil)
+	ExpectEquality(t, ws.SetSeed(1234567890123), nil)
+	ExpectEquality(t, cell(t, ws, "B1").SetSettings(engine.Settings{Format: "%.2f"}), nil)
+
+	l, err := bitfields.Parse("LOW[3:0] HIGH[7:4]")
+	ExpectEquality(t, err, nil)
+	ws.SetLayout("nibbles", l)
+	cell(t, ws, "A1").SetLayout("nibbles")
+
+	ExpectEquality(t, ws.SetScenario("start", []*cells.Cell{cell(t, ws, "A1")}), nil)
+	rng, err := cells.RangeFromReference("A1:B1")
+	ExpectEquality(t, err, nil)
+	ExpectEquality(t, ws.AddRule(worksheet.Rule{Range: rng, Condition: "{.} > 255", Format: worksheet.Format{Icon: "!"}}), nil)
+	ExpectEquality(t, ws.SetValidation(validation.Rule{Kind: validation.KindInteger}, cell(t, ws, "A1")), nil)
+
+	// the cells of a worksheet in manual calculation mode are calculated when
+	// the worksheet is loaded
+	ws.SetCalculation(worksheet.CalculationManual)
+	it := worksheet.Iteration{Enabled: true, MaxIterations: 10, Tolerance: 0.5}
+	ExpectEquality(t, ws.SetIteration(it), nil)
+
+	var b bytes.Buffer
+	ExpectEquality(t, ws.Save(&b), nil)
+
+	eng := ivy.New()
+	ld, err := worksheet.Load(&eng, &b, func(*cells.Cell) {})
+	ExpectEquality(t, err, nil)
+
+	ExpectEquality(t, ld.Calculation(), worksheet.CalculationManual)
+	ExpectEquality(t, ld.DirtyCount(), 0)
+	ExpectEquality(t, ld.Iteration(), it)
+	ExpectEquality(t, ld.DefaultSettings(), engine.Settings{MaxDigits: 100})
+	ExpectEquality(t, cell(t, ld, "A1").Entry, "255")
+	ExpectEquality(t, cell(t, ld, "A1").Result(), "ff")
+	ExpectEquality(t, cell(t, ld, "A1").BaseOverride(), engine.Base{Output: 16})
+	ExpectEquality(t, cell(t, ld, "B1").SettingsOverride(), engine.Settings{Format: "%.2f"})
+	ExpectEquality(t, cell(t, ld, "B1").Result(), "256.00")
+
+	// the volatile cell has the same value for the same seed
+	ExpectEquality(t, ld.Seed(), int64(1234567890123))
+	ExpectEquality(t, cell(t, ld, "D1").Result(), cell(t, ws, "D1").Result())
+
+	_, ok := ld.Layout("nibbles")
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, cell(t, ld, "A1").Layout(), "nibbles")
+
+	ExpectEquality(t, len(ld.Scenarios()), 1)
+	e, ok := ld.Scenarios()[0].Entry(cell(t, ld, "A1"))
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, e, "255")
+
+	ExpectEquality(t, len(ld.Rules()), 1)
+	_, ok = ld.ConditionalFormat(cell(t, ld, "A1"))
+	ExpectEquality(t, ok, false)
+	f, ok := ld.ConditionalFormat(cell(t, ld, "B1"))
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, f.Icon, "!")
+
+	r, ok := ld.Validation(cell(t, ld, "A1"))
+	ExpectEquality(t, ok, true)
+	ExpectEquality(t, r.Kind, validation.KindInteger)
+
+	// field references in the loaded worksheet use the loaded layouts
+	edit(t, ld, "C1", "{A1.HIGH}")
+	ExpectEquality(t, cell(t, ld, "C1").Error(), nil)
+	ExpectEquality(t, cell(t, ld, "C1").Result(), "15")
+
+	_, err = worksheet.Load(&eng, bytes.NewBufferString(`{"Version": 99}`), func(*cells.Cell) {})
+	ExpectedError(t, err, worksheet.UnsupportedFile)
+}